@@ -1,94 +1,315 @@
 package main
 
 import (
+	"slices"
 	"strings"
 
-	onCallAPI "github.com/grafana/amixr-api-go-client"
-	"github.com/grafana/crossplane-provider-grafana/v2/apis/cluster/alerting/v1alpha1"
-	"github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"github.com/grafana/grafana-openapi-client-go/client/provisioning"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"k8s.io/apimachinery/pkg/runtime"
 
 	"github.com/crossplane/function-sdk-go/errors"
 	"github.com/crossplane/function-sdk-go/resource"
 )
 
+const (
+	kindContactPoint = "ContactPoint"
+	kindMuteTiming   = "MuteTiming"
+)
+
 // AlertingClient is a client with convenience methods
 type AlertingClient struct {
-	Client       *client.GrafanaHTTPAPI
-	OnCallClient *onCallAPI.Client
+	Grafana  *GrafanaClient
+	OnCall   *OnCallClient
+	Settings v1beta1.Alerting
+
+	// Composed holds the names of alerting resources composed in the same pipeline by kind
+	Composed      map[string][]string
+	ContactPoints models.ContactPoints
+	MuteTimings   models.MuteTimings
 }
 
 // NewAlertingClient returns a client with convenience methods for Grafana and OnCall
-func NewAlertingClient(grafanaClient *client.GrafanaHTTPAPI, oncallClient *onCallAPI.Client) *AlertingClient {
+func NewAlertingClient(grafana *GrafanaClient, oncall *OnCallClient, settings v1beta1.Alerting) *AlertingClient {
 	return &AlertingClient{
-		Client:       grafanaClient,
-		OnCallClient: oncallClient,
+		Grafana:  grafana,
+		OnCall:   oncall,
+		Settings: settings,
 	}
 }
 
 // Process processes fields of different kinds
 func (c *AlertingClient) Process(desired *resource.DesiredComposed) error {
 	gvk := desired.Resource.GroupVersionKind()
-	// switch gvk.Kind {
-	// case "ContactPoint":
-	if gvk.Kind == "ContactPoint" {
+	switch gvk.Kind {
+	case kindContactPoint:
 		path := "spec.forProvider.oncall"
 		return replacePath(desired, path, c.GetOnCallURLs)
+
+	case "NotificationPolicy":
+		refs := &alertingReferences{client: c, kind: gvk.Kind}
+
+		// the root policy holds the default contact point and the nested policies
+		path := "spec.forProvider"
+		if err := replacePath(desired, path, func(policy map[string]any) (map[string]any, error) {
+			refs.policy(policy)
+			return policy, nil
+		}); err != nil {
+			return err
+		}
+		return refs.err()
+
+	case "RuleGroup":
+		path := "spec.forProvider.folderUid"
+		if err := replaceReferencePath(desired, path, c.Grafana.GetFolderUID); err != nil {
+			return err
+		}
+
+		refs := &alertingReferences{client: c, kind: gvk.Kind}
+		path = "spec.forProvider.rule"
+		if err := replacePath(desired, path, refs.rules); err != nil {
+			return err
+		}
+		return refs.err()
 	}
 	return nil
 }
 
-// GetOnCallURLs looks up OnCall integrations and returns the URLs
-func (c *AlertingClient) GetOnCallURLs(oncall []v1alpha1.OncallParameters) ([]v1alpha1.OncallParameters, error) {
-	newVal := make([]v1alpha1.OncallParameters, 0)
-	for _, params := range oncall {
-		// Skip if URL is nil
-		if params.URL == nil {
-			newVal = append(newVal, params)
+// composedAlertingNames returns the names of the alerting resources in the desired composed resources by kind
+func composedAlertingNames(desiredComposed map[resource.Name]*resource.DesiredComposed) map[string][]string {
+	names := map[string][]string{}
+	for _, desired := range desiredComposed {
+		gvk := desired.Resource.GroupVersionKind()
+		if gvk.Group != "alerting.grafana.crossplane.io" {
 			continue
 		}
-
-		if strings.HasPrefix(*params.URL, "http://") || strings.HasPrefix(*params.URL, "https://") {
-			newVal = append(newVal, params)
+		name, err := desired.Resource.GetString("spec.forProvider.name")
+		if err != nil || name == "" {
 			continue
 		}
+		names[gvk.Kind] = append(names[gvk.Kind], name)
+	}
+	return names
+}
+
+func (c *AlertingClient) getContactPoints() error {
+	// only populate the list if the list is empty
+	if len(c.ContactPoints) != 0 {
+		return nil
+	}
+
+	resp, err := c.Grafana.Client.Provisioning.GetContactpoints(provisioning.NewGetContactpointsParams())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list contact points")
+	}
+	c.ContactPoints = resp.GetPayload()
+	return nil
+}
+
+func (c *AlertingClient) getMuteTimings() error {
+	// only populate the list if the list is empty
+	if len(c.MuteTimings) != 0 {
+		return nil
+	}
+
+	resp, err := c.Grafana.Client.Provisioning.GetMuteTimings()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list mute timings")
+	}
+	c.MuteTimings = resp.GetPayload()
+	return nil
+}
+
+// GetContactPointName checks that a contact point exists and returns its name
+func (c *AlertingClient) GetContactPointName(name string) (string, error) {
+	if slices.Contains(c.Composed[kindContactPoint], name) {
+		return name, nil
+	}
+
+	if err := c.getContactPoints(); err != nil {
+		return "", err
+	}
+
+	idx := slices.IndexFunc(c.ContactPoints, func(cp *models.EmbeddedContactPoint) bool {
+		return cp.Name == name
+	})
+	if idx == -1 && c.Settings.CanonicalNames {
+		idx = slices.IndexFunc(c.ContactPoints, func(cp *models.EmbeddedContactPoint) bool {
+			return strings.EqualFold(cp.Name, name) || cp.UID == name
+		})
+	}
+	if idx != -1 {
+		return c.ContactPoints[idx].Name, nil
+	}
+
+	return "", errors.Errorf("Could not find contact point with name %s", name)
+}
+
+// GetMuteTimingName checks that a mute timing exists and returns its name
+func (c *AlertingClient) GetMuteTimingName(name string) (string, error) {
+	if slices.Contains(c.Composed[kindMuteTiming], name) {
+		return name, nil
+	}
+
+	if err := c.getMuteTimings(); err != nil {
+		return "", err
+	}
 
-		url, err := c.GetOnCallURL(*params.URL)
-		if err != nil {
-			return nil, err
+	idx := slices.IndexFunc(c.MuteTimings, func(mt *models.MuteTimeInterval) bool {
+		return mt.Name == name
+	})
+	if idx == -1 && c.Settings.CanonicalNames {
+		idx = slices.IndexFunc(c.MuteTimings, func(mt *models.MuteTimeInterval) bool {
+			return strings.EqualFold(mt.Name, name)
+		})
+	}
+	if idx != -1 {
+		return c.MuteTimings[idx].Name, nil
+	}
+
+	return "", errors.Errorf("Could not find mute timing with name %s", name)
+}
+
+// alertingReferences collects the unresolved references of an alerting resource, so they
+// can be reported at once
+type alertingReferences struct {
+	client *AlertingClient
+	kind   string
+	errs   []error
+}
+
+// resolve looks up a reference, found is false for an optional reference that cannot be resolved
+func (r *alertingReferences) resolve(s string, fn func(string) (string, error)) (newName string, found bool) {
+	newName, found, err := lookupReference(s, fn)
+	if err != nil {
+		r.errs = append(r.errs, err)
+		return s, true
+	}
+	return newName, found
+}
+
+func (r *alertingReferences) resolveKey(obj map[string]any, key string, fn func(string) (string, error)) {
+	s, ok := obj[key].(string)
+	if !ok {
+		return
+	}
+	if newName, found := r.resolve(s, fn); found {
+		obj[key] = newName
+	} else {
+		delete(obj, key)
+	}
+}
+
+func (r *alertingReferences) policy(policy map[string]any) {
+	r.resolveKey(policy, "contactPoint", r.client.GetContactPointName)
+
+	for _, key := range []string{"muteTimings", "activeTimings"} {
+		timings, ok := policy[key].([]any)
+		if !ok {
+			continue
+		}
+		newTimings := make([]any, 0, len(timings))
+		for _, timing := range timings {
+			if name, ok := timing.(string); ok {
+				newName, found := r.resolve(name, r.client.GetMuteTimingName)
+				if !found {
+					continue
+				}
+				timing = newName
+			}
+			newTimings = append(newTimings, timing)
 		}
-		params.URL = &url
-		newVal = append(newVal, params)
+		policy[key] = newTimings
+	}
+
+	for _, nested := range nestedObjects(policy, "policy") {
+		r.policy(nested)
 	}
-	return newVal, nil
 }
 
-// GetOnCallURL looks up an OnCall integration by name and returns its URL
-func (c *AlertingClient) GetOnCallURL(name string) (string, error) {
-	page := 1
-	for {
-		options := &onCallAPI.ListIntegrationOptions{
-			ListOptions: onCallAPI.ListOptions{
-				Page: page,
-			},
+func (r *alertingReferences) rules(rules []map[string]any) ([]map[string]any, error) {
+	for _, rule := range rules {
+		for _, data := range nestedObjects(rule, "data") {
+			r.resolveKey(data, "datasourceUid", r.client.Grafana.GetDataSourceUID)
 		}
-		response, _, err := c.OnCallClient.Integrations.ListIntegrations(options)
-		if err != nil {
-			return "", errors.Wrapf(err, "Failed to list oncall integrations")
+		for _, record := range nestedObjects(rule, "record") {
+			r.resolveKey(record, "targetDatasourceUid", r.client.Grafana.GetDataSourceUID)
 		}
+		for _, settings := range nestedObjects(rule, "notificationSettings") {
+			r.resolveKey(settings, "contactPoint", r.client.GetContactPointName)
+		}
+	}
+	return rules, nil
+}
 
-		// Look for the integration by name
-		for _, integration := range response.Integrations {
-			if integration.Name == name {
-				return integration.Link, nil
-			}
+func (r *alertingReferences) err() error {
+	if len(r.errs) == 0 {
+		return nil
+	}
+	return errors.Wrapf(errors.Join(r.errs...), "Could not resolve %s references", r.kind)
+}
+
+// nestedObjects returns the objects in a list of objects at key
+func nestedObjects(obj map[string]any, key string) []map[string]any {
+	list, _ := obj[key].([]any)
+	objects := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if o, ok := item.(map[string]any); ok {
+			objects = append(objects, o)
 		}
+	}
+	return objects
+}
 
-		// If no more pages, break
-		if response.Next == nil {
-			break
+// GetOnCallURLs looks up OnCall integrations and returns the URLs, the URL may refer to an
+// integration by name, by "team/integration-name" or with a selector object
+func (c *AlertingClient) GetOnCallURLs(oncall []map[string]any) ([]map[string]any, error) {
+	newOnCall := make([]map[string]any, 0, len(oncall))
+	for _, params := range oncall {
+		switch url := params["url"].(type) {
+		case string:
+			if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
+				break
+			}
+
+			link, found, err := lookupReference(url, func(ref string) (string, error) {
+				selector, err := c.OnCall.ParseIntegrationRef(ref)
+				if err != nil {
+					return ref, err
+				}
+				return c.GetOnCallURL(selector)
+			})
+			if err != nil {
+				return nil, err
+			}
+			// an optional integration that cannot be found removes its integration entry
+			if !found {
+				continue
+			}
+			params["url"] = link
+
+		case map[string]any:
+			var selector IntegrationSelector
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(url, &selector); err != nil {
+				return nil, errors.Wrapf(err, "Invalid oncall integration selector")
+			}
+			link, err := c.GetOnCallURL(selector)
+			if err != nil {
+				return nil, err
+			}
+			params["url"] = link
 		}
-		page++
+		newOnCall = append(newOnCall, params)
 	}
+	return newOnCall, nil
+}
 
-	return "", errors.Errorf("Could not find oncall integration with name %s", name)
+// GetOnCallURL looks up an OnCall integration and returns its URL
+func (c *AlertingClient) GetOnCallURL(selector IntegrationSelector) (string, error) {
+	integration, err := c.OnCall.FindIntegration(selector)
+	if err != nil {
+		return "", err
+	}
+	return integration.Link, nil
 }
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestAlertingNotificationPolicy(t *testing.T) {
+	policy := `{
+		"apiVersion": "alerting.grafana.crossplane.io/v1alpha1",
+		"kind": "NotificationPolicy",
+		"spec": {
+			"forProvider": {
+				"contactPoint": "%s",
+				"policy": [{
+					"contactPoint": "platform",
+					"muteTimings": ["Weekends"],
+					"policy": [{"contactPoint": "payments", "activeTimings": ["%s"]}]
+				}]
+			}
+		}
+	}`
+
+	cases := map[string]struct {
+		reason   string
+		settings v1beta1.Alerting
+		refs     []any
+		want     map[string]any
+		err      bool
+	}{
+		"ExactNames": {
+			reason: "Existing and composed names should be kept as-is",
+			refs:   []any{"default", "business-hours"},
+			want: map[string]any{
+				"contactPoint": "default",
+				"policy": []any{map[string]any{
+					"contactPoint": "platform",
+					"muteTimings":  []any{"Weekends"},
+					"policy":       []any{map[string]any{"contactPoint": "payments", "activeTimings": []any{"business-hours"}}},
+				}},
+			},
+		},
+		"CanonicalNames": {
+			reason:   "References should be mapped to canonical names when enabled",
+			settings: v1beta1.Alerting{CanonicalNames: true},
+			refs:     []any{"cp-default-uid", "Business-Hours"},
+			want: map[string]any{
+				"contactPoint": "default",
+				"policy": []any{map[string]any{
+					"contactPoint": "platform",
+					"muteTimings":  []any{"Weekends"},
+					"policy":       []any{map[string]any{"contactPoint": "payments", "activeTimings": []any{"business-hours"}}},
+				}},
+			},
+		},
+		"MissingReferences": {
+			reason: "Missing references should be reported",
+			refs:   []any{"Default", "unknown"},
+			err:    true,
+		},
+		"Modifiers": {
+			reason: "A fallback should replace a missing contact point and a missing optional timing should be removed",
+			refs:   []any{"unknown ?? default", "optional:unknown"},
+			want: map[string]any{
+				"contactPoint": "default",
+				"policy": []any{map[string]any{
+					"contactPoint": "platform",
+					"muteTimings":  []any{"Weekends"},
+					"policy":       []any{map[string]any{"contactPoint": "payments", "activeTimings": []any{}}},
+				}},
+			},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			if err := desired.Resource.UnmarshalJSON([]byte(fmt.Sprintf(policy, tc.refs...))); err != nil {
+				t.Fatal(err)
+			}
+
+			c := &AlertingClient{
+				Settings: tc.settings,
+				Composed: map[string][]string{kindContactPoint: {"payments"}},
+				ContactPoints: models.ContactPoints{
+					{Name: "default", UID: "cp-default-uid"},
+					{Name: "platform", UID: "cp-platform-uid"},
+				},
+				MuteTimings: models.MuteTimings{
+					{Name: "Weekends"},
+					{Name: "business-hours"},
+				},
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, _ := desired.Resource.GetValue("spec.forProvider")
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestAlertingRuleGroup(t *testing.T) {
+	ruleGroup := `{
+		"apiVersion": "alerting.grafana.crossplane.io/v1alpha1",
+		"kind": "RuleGroup",
+		"spec": {
+			"forProvider": {
+				"folderUid": "%s",
+				"rule": [{
+					"name": "HighLatency",
+					"data": [
+						{"refId": "A", "datasourceUid": "%s"},
+						{"refId": "B", "datasourceUid": "__expr__"}
+					],
+					"notificationSettings": [{"contactPoint": "%s"}]
+				}]
+			}
+		}
+	}`
+
+	cases := map[string]struct {
+		reason string
+		refs   []any
+		want   []any
+		err    bool
+	}{
+		"FolderTitle": {
+			reason: "A unique folder title and data source name should resolve to their UIDs",
+			refs:   []any{"Payments", "Mimir", "default"},
+			want:   []any{"payments-uid", "mimir-uid", "default"},
+		},
+		"FolderPath": {
+			reason: "A folder path should resolve to the nested folder UID",
+			refs:   []any{"Platform/Alerts", "mimir-uid", "default"},
+			want:   []any{"platform-alerts-uid", "mimir-uid", "default"},
+		},
+		"AmbiguousFolderTitle": {
+			reason: "A folder title that exists in several parents should return an error",
+			refs:   []any{"Alerts", "Mimir", "default"},
+			err:    true,
+		},
+		"MissingReferences": {
+			reason: "Unknown data sources and contact points should return an error",
+			refs:   []any{"Payments", "Loki", "unknown"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			if err := desired.Resource.UnmarshalJSON([]byte(fmt.Sprintf(ruleGroup, tc.refs...))); err != nil {
+				t.Fatal(err)
+			}
+
+			c := &AlertingClient{
+				Grafana: &GrafanaClient{
+					DataSources: models.DataSourceList{{Name: "Mimir", UID: "mimir-uid"}},
+					Folders: models.HitList{
+						{Title: "Payments", UID: "payments-uid"},
+						{Title: "Platform", UID: "platform-uid"},
+						{Title: "Alerts", UID: "platform-alerts-uid", FolderUID: "platform-uid"},
+						{Title: "Alerts", UID: "payments-alerts-uid", FolderUID: "payments-uid"},
+					},
+				},
+				ContactPoints: models.ContactPoints{{Name: "default"}},
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			folderUID, _ := desired.Resource.GetString("spec.forProvider.folderUid")
+			datasourceUID, _ := desired.Resource.GetString("spec.forProvider.rule[0].data[0].datasourceUid")
+			contactPoint, _ := desired.Resource.GetString("spec.forProvider.rule[0].notificationSettings[0].contactPoint")
+			got := []any{folderUID, datasourceUID, contactPoint}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
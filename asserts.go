@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	assertsapi "github.com/grafana/grafana-asserts-public-clients/go/gcom"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const (
+	assertsScopeEnv  = "env"
+	assertsScopeSite = "site"
+)
+
+// assertsScopeProperties maps the match properties and labels that refer to an environment or
+// site to their entity scope
+var assertsScopeProperties = map[string]string{
+	"env":          assertsScopeEnv,
+	"environment":  assertsScopeEnv,
+	"asserts_env":  assertsScopeEnv,
+	"site":         assertsScopeSite,
+	"asserts_site": assertsScopeSite,
+}
+
+// AssertsClient is a client with convenience methods
+type AssertsClient struct {
+	Client  *assertsapi.APIClient
+	Grafana *GrafanaClient
+	StackID int64
+
+	// Scopes holds the known values by entity scope, for example env and site
+	Scopes     map[string][]string
+	ModelRules []string
+}
+
+// NewAssertsClient returns a client with convenience methods for Asserts and Grafana, the stack
+// ID scopes every Asserts request
+func NewAssertsClient(client *assertsapi.APIClient, grafana *GrafanaClient, stackID int64) *AssertsClient {
+	return &AssertsClient{
+		Client:  client,
+		Grafana: grafana,
+		StackID: stackID,
+	}
+}
+
+func (c *AssertsClient) scopeOrgID() (string, error) {
+	if c.StackID == 0 {
+		return "", errors.New("Asserts lookups require the stack_id credential or stackId on the providerConfig")
+	}
+	return strconv.FormatInt(c.StackID, 10), nil
+}
+
+func (c *AssertsClient) getScopes() error {
+	// only populate the list if the list is empty
+	if len(c.Scopes) != 0 {
+		return nil
+	}
+
+	orgID, err := c.scopeOrgID()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	resp, _, err := c.Client.EntityScopeControllerAPI.GetAllEntityScopes(ctx).XScopeOrgID(orgID).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list Asserts entity scopes")
+	}
+
+	c.Scopes = resp.GetScopeValues()
+	return nil
+}
+
+func (c *AssertsClient) getModelRules() error {
+	// only populate the list if the list is empty
+	if len(c.ModelRules) != 0 {
+		return nil
+	}
+
+	orgID, err := c.scopeOrgID()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	resp, _, err := c.Client.ModelRulesConfigurationAPI.ListModelRules(ctx).XScopeOrgID(orgID).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list Asserts custom model rules")
+	}
+
+	c.ModelRules = resp.GetRuleNames()
+	return nil
+}
+
+// Process processes fields of different kinds
+func (c *AssertsClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	switch gvk.Kind {
+	case "LogConfig", "ProfileConfig", "TraceConfig":
+		path := "spec.forProvider.dataSourceUid"
+		if err := replaceReferencePath(desired, path, c.Grafana.GetDataSourceUID); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.match"
+		return replacePath(desired, path, c.GetMatchRules)
+
+	case "NotificationAlertsConfig", "SuppressedAssertionsConfig":
+		path := "spec.forProvider.matchLabels"
+		return replacePath(desired, path, c.GetMatchLabels)
+
+	case "CustomModelRules":
+		path := "spec.forProvider.rules"
+		return replacePath(desired, path, c.GetEnrichedBy)
+	}
+	return nil
+}
+
+// GetMatchRules replaces the environment and site names in match rules with the names known to
+// Asserts
+func (c *AssertsClient) GetMatchRules(match []map[string]any) ([]map[string]any, error) {
+	for _, rule := range match {
+		property, _ := rule["property"].(string)
+		scope, ok := assertsScopeProperties[property]
+		if !ok {
+			continue
+		}
+
+		values, ok := rule["values"].([]any)
+		if !ok {
+			continue
+		}
+		newValues, err := replaceReferenceItems(values, func(name string) (string, error) {
+			return c.GetScopeValue(scope, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+		rule["values"] = newValues
+	}
+	return match, nil
+}
+
+// GetMatchLabels replaces the environment and site names in match labels with the names known to
+// Asserts
+func (c *AssertsClient) GetMatchLabels(labels map[string]string) (map[string]string, error) {
+	for key, name := range labels {
+		scope, ok := assertsScopeProperties[key]
+		if !ok {
+			continue
+		}
+		v, found, err := lookupReference(name, func(name string) (string, error) {
+			return c.GetScopeValue(scope, name)
+		})
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			delete(labels, key)
+			continue
+		}
+		labels[key] = v
+	}
+	return labels, nil
+}
+
+// GetScopeValue looks up an environment or site by name, case-insensitively, and returns the name
+// known to Asserts
+func (c *AssertsClient) GetScopeValue(scope, name string) (string, error) {
+	if err := c.getScopes(); err != nil {
+		return name, err
+	}
+
+	return findName("Asserts "+scope, c.Scopes[scope], name)
+}
+
+// GetEnrichedBy checks that the custom model rules the entities are enriched by exist and
+// replaces them with the names known to Asserts
+func (c *AssertsClient) GetEnrichedBy(rules []map[string]any) ([]map[string]any, error) {
+	for _, rule := range rules {
+		for _, entity := range nestedObjects(rule, "entity") {
+			enrichedBy, ok := entity["enrichedBy"].([]any)
+			if !ok {
+				continue
+			}
+			newEnrichedBy, err := replaceReferenceItems(enrichedBy, c.GetModelRuleName)
+			if err != nil {
+				return nil, err
+			}
+			entity["enrichedBy"] = newEnrichedBy
+		}
+	}
+	return rules, nil
+}
+
+// GetModelRuleName looks up a custom model rule by name, case-insensitively, and returns the name
+// known to Asserts
+func (c *AssertsClient) GetModelRuleName(name string) (string, error) {
+	if err := c.getModelRules(); err != nil {
+		return name, err
+	}
+
+	return findName("Asserts custom model rules", c.ModelRules, name)
+}
+
+// findName returns the exact match of a name, or else the only case-insensitive match
+func findName(kind string, names []string, name string) (string, error) {
+	matches := []string{}
+	for _, n := range names {
+		if n == name {
+			return n, nil
+		}
+		if strings.EqualFold(n, name) {
+			matches = append(matches, n)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find %s %s", kind, name)
+	default:
+		return name, errors.Errorf("Found %d %s named %s: %s", len(matches), kind, name, strings.Join(matches, ", "))
+	}
+}
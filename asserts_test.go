@@ -0,0 +1,129 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/go-cmp/cmp"
+	assertsapi "github.com/grafana/grafana-asserts-public-clients/go/gcom"
+	goapi "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestAssertsProcess(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/datasources", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.DataSourceList{
+			{UID: "logs-uid", Name: "grafanacloud-logs", Type: "loki"},
+		})
+	})
+	mux.HandleFunc("/v1/entity_scope", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Scope-OrgID"); got != "123" {
+			t.Errorf("unexpected X-Scope-OrgID header: %q", got)
+		}
+		writeJSON(t, w, map[string]any{
+			"scopeValues": map[string][]string{
+				"env":  {"Production", "staging"},
+				"site": {"us-east-1", "us-west-2"},
+			},
+		})
+	})
+	mux.HandleFunc("/v1/config/model-rules", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{"ruleNames": []string{"kafka-topics", "Custom-Services"}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+	cfg := assertsapi.NewConfiguration()
+	cfg.Servers = assertsapi.ServerConfigurations{{URL: srv.URL}}
+
+	cases := map[string]struct {
+		reason string
+		kind   string
+		val    string
+		want   map[string]any
+		err    bool
+	}{
+		"LogConfig": {
+			reason: "A data source name should resolve to its UID, environment and site names to the Asserts names",
+			kind:   "LogConfig",
+			val:    `{"dataSourceUid": "grafanacloud-logs", "match": [{"property": "environment", "op": "=", "values": ["production"]}, {"property": "site", "op": "=", "values": ["us-east-1"]}, {"property": "service", "op": "=", "values": ["api"]}]}`,
+			want: map[string]any{
+				"dataSourceUid": "logs-uid",
+				"match": []any{
+					map[string]any{"property": "environment", "op": "=", "values": []any{"Production"}},
+					map[string]any{"property": "site", "op": "=", "values": []any{"us-east-1"}},
+					map[string]any{"property": "service", "op": "=", "values": []any{"api"}},
+				},
+			},
+		},
+		"MatchLabels": {
+			reason: "Environment labels should resolve to the Asserts names, other labels are kept",
+			kind:   "SuppressedAssertionsConfig",
+			val:    `{"matchLabels": {"asserts_env": "STAGING", "alertname": "HighLatency"}}`,
+			want: map[string]any{
+				"matchLabels": map[string]any{"asserts_env": "staging", "alertname": "HighLatency"},
+			},
+		},
+		"UnknownSite": {
+			reason: "An unknown site should return an error",
+			kind:   "NotificationAlertsConfig",
+			val:    `{"matchLabels": {"asserts_site": "eu-west-1"}}`,
+			err:    true,
+		},
+		"CustomModelRules": {
+			reason: "Custom model rule references should resolve to the Asserts names",
+			kind:   "CustomModelRules",
+			val:    `{"rules": [{"entity": [{"type": "Service", "enrichedBy": ["custom-services"]}]}]}`,
+			want: map[string]any{
+				"rules": []any{
+					map[string]any{"entity": []any{
+						map[string]any{"type": "Service", "enrichedBy": []any{"Custom-Services"}},
+					}},
+				},
+			},
+		},
+	}
+
+	c := NewAssertsClient(assertsapi.NewAPIClient(cfg), NewGrafanaClient(grafana), 123)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "asserts.grafana.crossplane.io/v1alpha1", "kind": "` + tc.kind + `", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/grafana-com-public-clients/go/gcom"
+	"github.com/hashicorp/go-version"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const (
+	realmTypeOrg   = "org"
+	realmTypeStack = "stack"
+)
+
+// CloudClient is a client with convenience methods
+type CloudClient struct {
+	Client  *gcom.APIClient
+	Regions []gcom.FormattedApiStackRegionAnyOf
+
+	// Stacks and Orgs hold the looked up stacks and orgs by slug and ID
+	Stacks map[string]*gcom.FormattedApiInstance
+	Orgs   map[string]*gcom.FormattedApiOrgPublic
+	// AccessPolicies holds the access policies by region
+	AccessPolicies map[string][]gcom.AuthAccessPolicy
+	// PluginVersions holds the catalog versions by plugin slug, highest first
+	PluginVersions map[string][]*version.Version
+
+	// PluginVersionTTL is how long a resolved plugin version is kept
+	PluginVersionTTL time.Duration
+	// PluginVersionTimeout is how long listing the versions of a plugin may take
+	PluginVersionTimeout time.Duration
+
+	now func() time.Time
+}
+
+// NewCloudClient returns a client with convenience methods
+func NewCloudClient(client *gcom.APIClient) *CloudClient {
+	return &CloudClient{
+		Client:               client,
+		Stacks:               map[string]*gcom.FormattedApiInstance{},
+		Orgs:                 map[string]*gcom.FormattedApiOrgPublic{},
+		AccessPolicies:       map[string][]gcom.AuthAccessPolicy{},
+		PluginVersions:       map[string][]*version.Version{},
+		PluginVersionTTL:     defaultPluginVersionTTL,
+		PluginVersionTimeout: defaultPluginVersionTimeout,
+		now:                  time.Now,
+	}
+}
+
+// Process processes fields of different kinds
+func (c *CloudClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	switch gvk.Kind {
+	case "AccessPolicy":
+		path := "spec.forProvider.region"
+		if err := replaceReferencePath(desired, path, c.GetRegionSlug); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.realm"
+		return replacePath(desired, path, c.GetRealms)
+
+	case "AccessPolicyToken", "AccessPolicyRotatingToken":
+		path := "spec.forProvider"
+		return replacePath(desired, path, c.GetAccessPolicyToken)
+
+	case "PluginInstallation":
+		path := "spec.forProvider.stackSlug"
+		if err := replaceReferencePath(desired, path, c.GetStackSlug); err != nil {
+			return err
+		}
+		return c.GetPluginInstallationVersion(desired)
+
+	case "StackServiceAccount", "StackServiceAccountToken":
+		path := "spec.forProvider.stackSlug"
+		return replaceReferencePath(desired, path, c.GetStackSlug)
+
+	case "PrivateDatasourceConnectNetwork":
+		path := "spec.forProvider.region"
+		if err := replaceReferencePath(desired, path, c.GetRegionSlug); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.stackIdentifier"
+		return replaceReferencePath(desired, path, c.GetStackID)
+
+	case "Stack":
+		path := "spec.forProvider.regionSlug"
+		return replaceReferencePath(desired, path, c.GetRegionSlug)
+	}
+	return nil
+}
+
+// GetRealms looks up the stack or org of each realm by slug and returns its ID as identifier
+func (c *CloudClient) GetRealms(realms []map[string]any) ([]map[string]any, error) {
+	newRealms := make([]map[string]any, 0, len(realms))
+	for _, realm := range realms {
+		var fn func(string) (string, error)
+		switch realm["type"] {
+		case realmTypeStack:
+			fn = c.GetStackID
+		case realmTypeOrg:
+			fn = c.GetOrgID
+		}
+
+		if identifier, ok := realm["identifier"].(string); ok && fn != nil {
+			id, found, err := lookupReference(identifier, fn)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			realm["identifier"] = id
+		}
+		newRealms = append(newRealms, realm)
+	}
+	return newRealms, nil
+}
+
+// GetAccessPolicyToken normalises the region of an access policy token and looks up its access
+// policy by ID or name in that region
+func (c *CloudClient) GetAccessPolicyToken(forProvider map[string]any) (map[string]any, error) {
+	if err := replaceReferenceKey(forProvider, "region", c.GetRegionSlug); err != nil {
+		return nil, err
+	}
+
+	region, ok := forProvider["region"].(string)
+	if !ok {
+		return forProvider, nil
+	}
+
+	err := replaceReferenceKey(forProvider, "accessPolicyId", func(name string) (string, error) {
+		return c.GetAccessPolicyID(region, name)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return forProvider, nil
+}
+
+// FindStack looks up a stack by slug or ID
+func (c *CloudClient) FindStack(slug string) (*gcom.FormattedApiInstance, error) {
+	if stack, ok := c.Stacks[slug]; ok {
+		return stack, nil
+	}
+
+	ctx := context.Background()
+	stack, _, err := c.Client.InstancesAPI.GetInstance(ctx, slug).Execute()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not find stack with slug or ID: %s", slug)
+	}
+
+	c.Stacks[stack.Slug] = stack
+	c.Stacks[strconv.Itoa(int(stack.Id))] = stack
+	return stack, nil
+}
+
+// GetStackID looks up a stack by slug and returns its ID
+func (c *CloudClient) GetStackID(slug string) (string, error) {
+	stack, err := c.FindStack(slug)
+	if err != nil {
+		return slug, err
+	}
+	return strconv.Itoa(int(stack.Id)), nil
+}
+
+// GetStackNumericID looks up a stack by slug and returns its ID as a number, for fields that
+// hold the stack ID as a number
+func (c *CloudClient) GetStackNumericID(slug string) (int64, error) {
+	stack, err := c.FindStack(slug)
+	if err != nil {
+		return 0, err
+	}
+	return int64(stack.Id), nil
+}
+
+// GetStackSlug looks up a stack by slug or ID and returns its slug
+func (c *CloudClient) GetStackSlug(slug string) (string, error) {
+	stack, err := c.FindStack(slug)
+	if err != nil {
+		return slug, err
+	}
+	return stack.Slug, nil
+}
+
+// GetStackFacts looks up a stack by slug or ID and returns its endpoints and instance IDs
+func (c *CloudClient) GetStackFacts(slug string) (map[string]string, error) {
+	stack, err := c.FindStack(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	connections, _, err := c.Client.InstancesAPI.GetConnections(ctx, stack.Slug).Execute()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get connections of stack %s", stack.Slug)
+	}
+
+	return map[string]string{
+		"stackId":              strconv.Itoa(int(stack.Id)),
+		"stackSlug":            stack.Slug,
+		"stackUrl":             stack.Url,
+		"prometheusUrl":        stack.HmInstancePromUrl,
+		"prometheusInstanceId": strconv.Itoa(int(stack.HmInstancePromId)),
+		"lokiUrl":              stack.HlInstanceUrl,
+		"lokiInstanceId":       strconv.Itoa(int(stack.HlInstanceId)),
+		"tempoUrl":             stack.HtInstanceUrl,
+		"tempoInstanceId":      strconv.Itoa(int(stack.HtInstanceId)),
+		"otlpUrl":              connections.GetOtlpHttpUrl(),
+	}, nil
+}
+
+// GetOrgID looks up an org by slug and returns its ID
+func (c *CloudClient) GetOrgID(slug string) (string, error) {
+	org, ok := c.Orgs[slug]
+	if !ok {
+		ctx := context.Background()
+		resp, _, err := c.Client.OrgsAPI.GetOrg(ctx, slug).Execute()
+		if err != nil {
+			return slug, errors.Wrapf(err, "Could not find org with slug or ID: %s", slug)
+		}
+		org = resp
+		c.Orgs[org.Slug] = org
+		c.Orgs[strconv.Itoa(int(org.Id))] = org
+	}
+	return strconv.Itoa(int(org.Id)), nil
+}
+
+func (c *CloudClient) getRegions() error {
+	// only populate the list if the list is empty
+	if len(c.Regions) != 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	resp, _, err := c.Client.StackRegionsAPI.GetStackRegions(ctx).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list stack regions")
+	}
+
+	for _, region := range resp.Items {
+		if region.FormattedApiStackRegionAnyOf != nil {
+			c.Regions = append(c.Regions, *region.FormattedApiStackRegionAnyOf)
+		}
+	}
+	return nil
+}
+
+// GetRegionSlug looks up a region by slug, ID or name and returns the slug the Cloud API expects
+func (c *CloudClient) GetRegionSlug(name string) (string, error) {
+	if err := c.getRegions(); err != nil {
+		return name, err
+	}
+
+	idx := slices.IndexFunc(c.Regions, func(r gcom.FormattedApiStackRegionAnyOf) bool {
+		return r.Slug == name
+	})
+	if idx == -1 {
+		idx = slices.IndexFunc(c.Regions, func(r gcom.FormattedApiStackRegionAnyOf) bool {
+			return strconv.Itoa(int(r.Id)) == name || strings.EqualFold(r.Name, name) || strings.EqualFold(r.PublicName, name)
+		})
+	}
+	if idx != -1 {
+		return c.Regions[idx].Slug, nil
+	}
+
+	return name, errors.Errorf("Could not find stack region with slug, ID or name: %s", name)
+}
+
+func (c *CloudClient) getAccessPolicies(region string) error {
+	// only populate the list if the list is empty
+	if _, ok := c.AccessPolicies[region]; ok {
+		return nil
+	}
+
+	ctx := context.Background()
+	resp, _, err := c.Client.AccesspoliciesAPI.GetAccessPolicies(ctx).Region(region).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list access policies in region %s", region)
+	}
+
+	c.AccessPolicies[region] = resp.Items
+	return nil
+}
+
+// GetAccessPolicyID looks up an access policy in a region by ID or name and returns its ID
+func (c *CloudClient) GetAccessPolicyID(region, name string) (string, error) {
+	if err := c.getAccessPolicies(region); err != nil {
+		return name, err
+	}
+
+	ids := []string{}
+	for _, policy := range c.AccessPolicies[region] {
+		if policy.GetId() == name {
+			return name, nil
+		}
+		if policy.Name == name {
+			ids = append(ids, policy.GetId())
+		}
+	}
+
+	switch len(ids) {
+	case 1:
+		return ids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find access policy with ID or name %s in region %s", name, region)
+	default:
+		return name, errors.Errorf("Found %d access policies named %s in region %s", len(ids), name, region)
+	}
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/grafana-com-public-clients/go/gcom"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestCloudProcess(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stack-regions", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"items": []map[string]any{
+				{"id": 1, "slug": "prod-eu-west-2", "name": "EU West 2", "publicName": "Europe (Ireland)"},
+				{"id": 2, "slug": "prod-us-east-0", "name": "US East 0", "publicName": "US (Virginia)"},
+			},
+		})
+	})
+	mux.HandleFunc("/instances/mystack", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{"id": 123, "slug": "mystack", "orgId": 7, "orgSlug": "myorg"})
+	})
+	mux.HandleFunc("/orgs/myorg", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{"id": 7, "slug": "myorg"})
+	})
+	mux.HandleFunc("/v1/accesspolicies", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("region"); got != "prod-eu-west-2" {
+			t.Errorf("unexpected region: %q", got)
+		}
+		writeJSON(t, w, map[string]any{
+			"items": []map[string]any{
+				{"id": "ap-1", "name": "metrics-write"},
+				{"id": "ap-2", "name": "logs-write"},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := gcom.NewConfiguration()
+	cfg.Servers = gcom.ServerConfigurations{{URL: srv.URL}}
+
+	cases := map[string]struct {
+		reason string
+		kind   string
+		val    string
+		want   map[string]any
+		err    bool
+	}{
+		"AccessPolicy": {
+			reason: "Region names and realm slugs should resolve to the region slug and IDs",
+			kind:   "AccessPolicy",
+			val:    `{"region": "Europe (Ireland)", "realm": [{"type": "stack", "identifier": "mystack"}, {"type": "org", "identifier": "myorg"}]}`,
+			want: map[string]any{
+				"region": "prod-eu-west-2",
+				"realm": []any{
+					map[string]any{"type": "stack", "identifier": "123"},
+					map[string]any{"type": "org", "identifier": "7"},
+				},
+			},
+		},
+		"AccessPolicyToken": {
+			reason: "An access policy name should resolve to its ID in the region of the token",
+			kind:   "AccessPolicyToken",
+			val:    `{"region": "prod-eu-west-2", "accessPolicyId": "logs-write"}`,
+			want: map[string]any{
+				"region":         "prod-eu-west-2",
+				"accessPolicyId": "ap-2",
+			},
+		},
+		"AccessPolicyTokenFallback": {
+			reason: "An access policy that cannot be found should be replaced by its fallback",
+			kind:   "AccessPolicyToken",
+			val:    `{"region": "prod-eu-west-2", "accessPolicyId": "traces-write ?? ap-1"}`,
+			want: map[string]any{
+				"region":         "prod-eu-west-2",
+				"accessPolicyId": "ap-1",
+			},
+		},
+		"OptionalRealm": {
+			reason: "A realm with an optional identifier that cannot be resolved should be removed",
+			kind:   "AccessPolicy",
+			val:    `{"region": "prod-eu-west-2", "realm": [{"type": "stack", "identifier": "optional:otherstack"}, {"type": "org", "identifier": "myorg"}]}`,
+			want: map[string]any{
+				"region": "prod-eu-west-2",
+				"realm":  []any{map[string]any{"type": "org", "identifier": "7"}},
+			},
+		},
+		"UnknownRegion": {
+			reason: "An unknown region should return an error",
+			kind:   "Stack",
+			val:    `{"regionSlug": "moon-0"}`,
+			err:    true,
+		},
+	}
+
+	c := NewCloudClient(gcom.NewAPIClient(cfg))
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "cloud.grafana.crossplane.io/v1alpha1", "kind": "` + tc.kind + `", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCloudInstallationStackID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/instances/mystack", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{"id": 123, "slug": "mystack"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := gcom.NewConfiguration()
+	cfg.Servers = gcom.ServerConfigurations{{URL: srv.URL}}
+	cloud := NewCloudClient(gcom.NewAPIClient(cfg))
+
+	cases := map[string]struct {
+		reason   string
+		resolver resolver
+		obj      string
+		want     any
+	}{
+		"SMInstallation": {
+			reason:   "A stack slug should resolve to the stack ID",
+			resolver: &SMClient{Cloud: cloud},
+			obj:      `{"apiVersion": "sm.grafana.crossplane.io/v1alpha1", "kind": "Installation", "spec": {"forProvider": {"stackId": "mystack"}}}`,
+			want:     "123",
+		},
+		"FrontendO11yApp": {
+			reason:   "A stack slug should resolve to the numeric stack ID",
+			resolver: NewFrontendO11yClient(nil, cloud),
+			obj:      `{"apiVersion": "frontendobservability.grafana.crossplane.io/v1alpha1", "kind": "App", "spec": {"forProvider": {"stackId": "mystack"}}}`,
+			want:     int64(123),
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			if err := desired.Resource.UnmarshalJSON([]byte(tc.obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := tc.resolver.Process(desired); err != nil {
+				t.Fatalf("%s\nProcess(...): unexpected error: %v", tc.reason, err)
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider.stackId")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nProcess(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
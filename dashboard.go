@@ -0,0 +1,132 @@
+package main
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-openapi-client-go/client/search"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/errors"
+)
+
+// playlistItemDashboardByUID is the playlist item type that refers to a dashboard by UID
+const playlistItemDashboardByUID = "dashboard_by_uid"
+
+func (c *GrafanaClient) getDashboards() error {
+	// only populate the list if the list is empty
+	if len(c.Dashboards) != 0 {
+		return nil
+	}
+
+	allDashboards := models.HitList{}
+	searchType := "dash-db"
+	limit := int64(1000)
+	page := int64(1)
+	for {
+		params := search.NewSearchParams().WithType(&searchType).WithLimit(&limit).WithPage(&page)
+		resp, err := c.Client.Search.Search(params)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list dashboards")
+		}
+		allDashboards = append(allDashboards, resp.GetPayload()...)
+
+		if int64(len(resp.GetPayload())) < limit {
+			break
+		}
+		page++
+	}
+	c.Dashboards = allDashboards
+	return nil
+}
+
+// GetDashboardUID will return the UID for a dashboard UID, title or "<folder path>/<title>"
+func (c *GrafanaClient) GetDashboardUID(name string) (string, error) {
+	if err := c.getDashboards(); err != nil {
+		return name, err
+	}
+
+	if slices.ContainsFunc(c.Dashboards, func(d *models.Hit) bool {
+		return d.UID == name
+	}) {
+		return name, nil
+	}
+
+	// dashboard titles may contain a slash, an exact title match takes precedence
+	uids := c.dashboardUIDs(name, nil)
+	if i := strings.LastIndex(name, "/"); i != -1 && len(uids) == 0 {
+		folderUID, err := c.GetFolderUID(name[:i])
+		if err != nil {
+			return name, err
+		}
+		uids = c.dashboardUIDs(name[i+1:], &folderUID)
+	}
+
+	switch len(uids) {
+	case 1:
+		return uids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find dashboard with UID or title: %s", name)
+	default:
+		return name, errors.Errorf("Found %d dashboards with title %s, use the folder path instead: %s", len(uids), name, strings.Join(uids, ", "))
+	}
+}
+
+// dashboardUIDs returns the UIDs of the dashboards with a title, optionally within a folder
+func (c *GrafanaClient) dashboardUIDs(title string, folderUID *string) []string {
+	uids := []string{}
+	for _, d := range c.Dashboards {
+		if d.Title == title && (folderUID == nil || d.FolderUID == *folderUID) {
+			uids = append(uids, d.UID)
+		}
+	}
+	return uids
+}
+
+// GetPreferences looks up the home dashboard of team preferences
+func (c *GrafanaClient) GetPreferences(preferences []map[string]any) ([]map[string]any, error) {
+	for _, p := range preferences {
+		if err := replaceReferenceKey(p, "homeDashboardUid", c.GetDashboardUID); err != nil {
+			return nil, err
+		}
+	}
+	return preferences, nil
+}
+
+// GetPlaylistItems looks up the dashboards of playlist items that refer to a dashboard by UID
+func (c *GrafanaClient) GetPlaylistItems(items []map[string]any) ([]map[string]any, error) {
+	newItems := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if name, ok := item["value"].(string); ok && item["type"] == playlistItemDashboardByUID {
+			uid, found, err := lookupReference(name, c.GetDashboardUID)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			item["value"] = uid
+		}
+		newItems = append(newItems, item)
+	}
+	return newItems, nil
+}
+
+// GetReportDashboards looks up the dashboards of a report
+func (c *GrafanaClient) GetReportDashboards(dashboards []map[string]any) ([]map[string]any, error) {
+	newDashboards := make([]map[string]any, 0, len(dashboards))
+	for _, d := range dashboards {
+		if name, ok := d["uid"].(string); ok {
+			uid, found, err := lookupReference(name, c.GetDashboardUID)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			d["uid"] = uid
+		}
+		newDashboards = append(newDashboards, d)
+	}
+	return newDashboards, nil
+}
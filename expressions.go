@@ -0,0 +1,257 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// expressionPattern matches inline lookups like ${grafana:team:platform}, the name runs up to the
+// closing brace
+var expressionPattern = regexp.MustCompile(`\$\{([a-z0-9]+):([a-z-]+):([^}]+)\}`)
+
+// expressionLookup looks up a name and returns the value that replaces the expression
+type expressionLookup func(name string) (string, error)
+
+// expressionLookups returns the lookups of inline expressions by source and kind, a source is nil
+// when the providerConfig has no credentials for it
+func expressionLookups(resolvers map[string]resolver) map[string]map[string]expressionLookup {
+	lookups := map[string]map[string]expressionLookup{
+		"grafana": nil,
+		"oncall":  nil,
+		"sm":      nil,
+		"cloud":   nil,
+		"k6":      nil,
+		"slo":     nil,
+		"ml":      nil,
+	}
+
+	if grafana, ok := resolvers["oss.grafana.crossplane.io"].(*GrafanaClient); ok && grafana.Client != nil {
+		lookups["grafana"] = map[string]expressionLookup{
+			"team":            grafana.GetTeam,
+			"user":            grafana.GetUser,
+			"service-account": grafana.GetServiceAccount,
+			"role":            grafana.GetRoleUID,
+			"folder":          grafana.GetFolderUID,
+			"datasource":      grafana.GetDataSourceUID,
+			"dashboard":       grafana.GetDashboardUID,
+			"library-panel":   grafana.GetLibraryPanelUID,
+		}
+	}
+	if oncall, ok := resolvers["oncall.grafana.crossplane.io"].(*OnCallClient); ok && oncall.Client != nil {
+		lookups["oncall"] = map[string]expressionLookup{
+			"user":             oncall.GetUserID,
+			"team":             oncall.GetTeamID,
+			"schedule":         oncall.GetScheduleID,
+			"integration":      oncall.GetIntegrationID,
+			"slack-channel":    oncall.GetSlackChannelID,
+			"slack-user-group": oncall.GetSlackUserGroupID,
+			"telegram-channel": oncall.GetTelegramChannelID,
+			"msteams-channel":  oncall.GetMSTeamsChannelID,
+		}
+	}
+	if sm, ok := resolvers["sm.grafana.crossplane.io"].(*SMClient); ok && sm.Client != nil {
+		lookups["sm"] = map[string]expressionLookup{
+			"probe": func(name string) (string, error) {
+				id, err := sm.GetProbeID(name)
+				return strconv.FormatInt(id, 10), err
+			},
+		}
+	}
+	if cloud, ok := resolvers["cloud.grafana.crossplane.io"].(*CloudClient); ok {
+		lookups["cloud"] = map[string]expressionLookup{
+			"stack":  cloud.GetStackID,
+			"org":    cloud.GetOrgID,
+			"region": cloud.GetRegionSlug,
+		}
+	}
+	if k6, ok := resolvers["k6.grafana.crossplane.io"].(*K6Client); ok {
+		lookups["k6"] = map[string]expressionLookup{
+			"project":   k6.GetProjectID,
+			"load-test": k6.GetLoadTestID,
+		}
+	}
+	if slo, ok := resolvers["slo.grafana.crossplane.io"].(*SLOClient); ok {
+		lookups["slo"] = map[string]expressionLookup{
+			"slo": slo.GetSLOUID,
+		}
+	}
+	if ml, ok := resolvers["ml.grafana.crossplane.io"].(*MLClient); ok {
+		lookups["ml"] = map[string]expressionLookup{
+			"holiday": ml.GetHolidayID,
+		}
+	}
+	return lookups
+}
+
+// GetSLOUID looks up an existing SLO by name and returns its UID, an existing UID is returned as-is,
+// SLO resources have no field that refers to another SLO, so only ${slo:slo:<name>} expressions
+// use it
+func (c *SLOClient) GetSLOUID(name string) (string, error) {
+	if err := c.getSLOs(); err != nil {
+		return name, err
+	}
+
+	uids := []string{}
+	for _, s := range c.SLOs {
+		if s.Uuid == name {
+			return name, nil
+		}
+		if s.Name == name {
+			uids = append(uids, s.Uuid)
+		}
+	}
+
+	switch len(uids) {
+	case 1:
+		return uids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find SLO with UID or name: %s", name)
+	default:
+		return name, errors.Errorf("Found %d SLOs named %s: %s", len(uids), name, strings.Join(uids, ", "))
+	}
+}
+
+// expandExpressions replaces the inline expressions in the strings of spec.forProvider, each
+// distinct expression is looked up once and the resource is only changed when all of them resolve
+func expandExpressions(desired *resource.DesiredComposed, lookups map[string]map[string]expressionLookup) error {
+	path := "spec.forProvider"
+	forProvider, err := desired.Resource.GetValue(path)
+	if err != nil {
+		//nolint:nilerr // simply return if no value found at path
+		return nil
+	}
+
+	// the values by expression, names within a JSON document are unescaped first
+	expressions := map[expression]string{}
+	walkStrings(forProvider, func(s string) string {
+		for _, m := range expressionPattern.FindAllStringSubmatch(s, -1) {
+			// expressions of other sources, like Grafana template variables, are kept as-is
+			if _, ok := lookups[m[1]]; ok {
+				expressions[parseExpression(m, isJSONDocument(s))] = ""
+			}
+		}
+		return s
+	})
+	if len(expressions) == 0 {
+		return nil
+	}
+
+	errs := []error{}
+	keys := slices.SortedFunc(maps.Keys(expressions), func(a, b expression) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	for _, expr := range keys {
+		v, err := lookupExpression(lookups, expr)
+		if err != nil {
+			errs = append(errs, errors.Wrapf(err, "%s", expr))
+			continue
+		}
+		expressions[expr] = v
+	}
+	if len(errs) != 0 {
+		return errors.Wrapf(errors.Join(errs...), "Could not resolve expressions of %s", desired.Resource.GetKind())
+	}
+
+	newVal := walkStrings(forProvider, func(s string) string {
+		isJSON := isJSONDocument(s)
+		return expressionPattern.ReplaceAllStringFunc(s, func(match string) string {
+			v, ok := expressions[parseExpression(expressionPattern.FindStringSubmatch(match), isJSON)]
+			if !ok {
+				return match
+			}
+			if isJSON {
+				// values within a JSON document are escaped as JSON strings
+				b, _ := json.Marshal(v)
+				v = string(b[1 : len(b)-1])
+			}
+			return v
+		})
+	})
+
+	if err := desired.Resource.SetValue(path, newVal); err != nil {
+		return errors.Wrapf(err, "cannot set value for %s", desired.Resource.GetKind())
+	}
+	return nil
+}
+
+// expression is a parsed inline lookup
+type expression struct {
+	Source string
+	Kind   string
+	Name   string
+}
+
+func (e expression) String() string {
+	return "${" + e.Source + ":" + e.Kind + ":" + e.Name + "}"
+}
+
+// parseExpression returns the expression of a match of expressionPattern, a name within a JSON
+// document is unescaped
+func parseExpression(m []string, isJSON bool) expression {
+	name := m[3]
+	if isJSON {
+		var unescaped string
+		if err := json.Unmarshal([]byte(`"`+name+`"`), &unescaped); err == nil {
+			name = unescaped
+		}
+	}
+	return expression{Source: m[1], Kind: m[2], Name: name}
+}
+
+// isJSONDocument reports whether a string holds a JSON object or array, like a dashboard configJson
+func isJSONDocument(s string) bool {
+	return (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && json.Valid([]byte(s))
+}
+
+// lookupExpression looks up the name of an expression with the lookup of its source and kind
+func lookupExpression(lookups map[string]map[string]expressionLookup, expr expression) (string, error) {
+	kinds := lookups[expr.Source]
+	if kinds == nil {
+		return "", errors.Errorf("providerConfig has no credentials for %s lookups", expr.Source)
+	}
+	lookup, ok := kinds[expr.Kind]
+	if !ok {
+		return "", errors.Errorf("Unknown %s lookup %s, expected one of: %s", expr.Source, expr.Kind, strings.Join(slices.Sorted(maps.Keys(kinds)), ", "))
+	}
+
+	// an optional reference that cannot be resolved expands to an empty string
+	ref := parseReference(expr.Name)
+	v, err := lookup(ref.Name)
+	if err != nil {
+		switch {
+		case ref.Fallback != nil:
+			return *ref.Fallback, nil
+		case ref.Optional:
+			return "", nil
+		}
+	}
+	return v, err
+}
+
+// walkStrings returns a copy of a value with fn applied to all of its strings
+func walkStrings(val any, fn func(string) string) any {
+	switch v := val.(type) {
+	case string:
+		return fn(v)
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = walkStrings(item, fn)
+		}
+		return m
+	case []any:
+		l := make([]any, len(v))
+		for i, item := range v {
+			l[i] = walkStrings(item, fn)
+		}
+		return l
+	}
+	return val
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/slo-openapi-client/go/slo"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestExpandExpressions(t *testing.T) {
+	lookup := func(values map[string]string) expressionLookup {
+		return func(name string) (string, error) {
+			v, ok := values[name]
+			if !ok {
+				return "", errors.Errorf("Could not find %s", name)
+			}
+			return v, nil
+		}
+	}
+	calls := 0
+	lookups := map[string]map[string]expressionLookup{
+		"grafana": {
+			"team": func(name string) (string, error) {
+				calls++
+				return lookup(map[string]string{"platform": "7"})(name)
+			},
+			"dashboard": lookup(map[string]string{`Team "A"/Overview`: "overview-a"}),
+		},
+		"oncall": {
+			"user": lookup(map[string]string{"alice@example.com": "UALICE"}),
+		},
+		"sm": nil,
+	}
+
+	cases := map[string]struct {
+		reason string
+		val    string
+		want   map[string]any
+		err    bool
+	}{
+		"NoExpressions": {
+			reason: "Strings without expressions and expressions of other sources should be kept",
+			val:    `{"name": "platform", "configJson": "{\"title\": \"${datasource}\", \"query\": \"${env:raw:x}\"}"}`,
+			want: map[string]any{
+				"name":       "platform",
+				"configJson": `{"title": "${datasource}", "query": "${env:raw:x}"}`,
+			},
+		},
+		"Nested": {
+			reason: "Expressions should be expanded in nested fields and as part of a string",
+			val:    `{"teamId": "${grafana:team:platform}", "users": ["${oncall:user:alice@example.com}"], "rules": [{"description": "owned by team ${grafana:team:platform}"}]}`,
+			want: map[string]any{
+				"teamId": "7",
+				"users":  []any{"UALICE"},
+				"rules":  []any{map[string]any{"description": "owned by team 7"}},
+			},
+		},
+		"JSONString": {
+			reason: "Values within a JSON string should be escaped",
+			val:    `{"configJson": "{\"links\": [{\"title\": \"${grafana:dashboard:Team \\\"A\\\"/Overview}\"}]}"}`,
+			want: map[string]any{
+				"configJson": `{"links": [{"title": "overview-a"}]}`,
+			},
+		},
+		"NotFound": {
+			reason: "An expression that does not resolve should return an error",
+			val:    `{"userId": "${oncall:user:bob@example.com}"}`,
+			err:    true,
+		},
+		"Modifiers": {
+			reason: "Optional expressions and expressions with a fallback should not return an error",
+			val:    `{"description": "on call: ${oncall:user:optional:bob@example.com}", "userId": "${oncall:user:bob@example.com ?? UNOBODY}"}`,
+			want: map[string]any{
+				"description": "on call: ",
+				"userId":      "UNOBODY",
+			},
+		},
+		"NoCredentials": {
+			reason: "An expression of a source without credentials should return an error",
+			val:    `{"probes": ["${sm:probe:Frankfurt}"]}`,
+			err:    true,
+		},
+		"UnknownKind": {
+			reason: "An unknown kind of a source should return an error",
+			val:    `{"folderUid": "${grafana:folders:Platform}"}`,
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Example", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := expandExpressions(desired, lookups)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nexpandExpressions(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nexpandExpressions(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+
+	if calls != 1 {
+		t.Errorf("expandExpressions(...): expected each distinct expression to be looked up once, got %d lookups", calls)
+	}
+}
+
+func TestSLOGetSLOUID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/slo", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, slo.ApiSLOListResponse{
+			Slos: []slo.SloV00Slo{
+				{Uuid: "abc", Name: "checkout availability"},
+				{Uuid: "def", Name: "checkout latency"},
+				{Uuid: "ghi", Name: "checkout latency"},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := slo.NewConfiguration()
+	cfg.Servers = slo.ServerConfigurations{{URL: srv.URL}}
+
+	cases := map[string]struct {
+		reason string
+		name   string
+		want   string
+		err    bool
+	}{
+		"UID": {
+			reason: "An existing SLO UID should be returned as-is",
+			name:   "def",
+			want:   "def",
+		},
+		"Name": {
+			reason: "A unique SLO name should resolve to its UID",
+			name:   "checkout availability",
+			want:   "abc",
+		},
+		"AmbiguousName": {
+			reason: "An SLO name with several matches should return an error",
+			name:   "checkout latency",
+			want:   "checkout latency",
+			err:    true,
+		},
+		"UnknownName": {
+			reason: "An unknown SLO should return an error",
+			name:   "unknown",
+			want:   "unknown",
+			err:    true,
+		},
+	}
+
+	c := NewSLOClient(slo.NewAPIClient(cfg), nil, nil)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetSLOUID(tc.name)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetSLOUID(%q): unexpected error: %v", tc.reason, tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetSLOUID(%q): -want, +got:\n%s", tc.reason, tc.name, diff)
+			}
+		})
+	}
+}
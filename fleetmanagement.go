@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"connectrpc.com/connect"
+	collectorv1 "github.com/grafana/fleet-management-api/api/gen/proto/go/collector/v1"
+
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// pipelineMatcherName matches the attribute name of a Prometheus Alertmanager matcher
+var pipelineMatcherName = regexp.MustCompile(`^\s*"?([^"=!~\s]+)"?\s*(=~|!~|!=|=)`)
+
+// FleetManagementClient is a client with convenience methods
+type FleetManagementClient struct {
+	Client     *clients.FleetManagementClient
+	Collectors []*collectorv1.Collector
+}
+
+// NewFleetManagementClient returns a client with convenience methods
+func NewFleetManagementClient(client *clients.FleetManagementClient) *FleetManagementClient {
+	return &FleetManagementClient{
+		Client: client,
+	}
+}
+
+func (c *FleetManagementClient) getCollectors() error {
+	// only populate the list if the list is empty
+	if len(c.Collectors) != 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	resp, err := c.Client.CollectorServiceClient.ListCollectors(ctx, connect.NewRequest(&collectorv1.ListCollectorsRequest{}))
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list collectors")
+	}
+
+	c.Collectors = resp.Msg.GetCollectors()
+	return nil
+}
+
+// Process processes fields of different kinds
+func (c *FleetManagementClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	switch gvk.Kind {
+	case "Collector":
+		// a collector registers itself, the managed resource adopts it by its ID as external name
+		name := meta.GetExternalName(desired.Resource)
+		if name == "" {
+			return nil
+		}
+		id, err := c.GetCollectorID(name)
+		if err != nil {
+			return err
+		}
+		meta.SetExternalName(desired.Resource, id)
+		return nil
+
+	case "Pipeline":
+		path := "spec.forProvider.matchers"
+		return replacePath(desired, path, c.CheckMatchers)
+	}
+	return nil
+}
+
+// GetCollectorID looks up a collector by ID, name or attributes and returns its ID
+//
+// Attributes are a comma separated list of <name>=<value> terms that all need to match, they
+// need to select exactly one collector.
+func (c *FleetManagementClient) GetCollectorID(name string) (string, error) {
+	if err := c.getCollectors(); err != nil {
+		return name, err
+	}
+
+	ids := []string{}
+	if strings.Contains(name, "=") {
+		selector, err := parseCollectorSelector(name)
+		if err != nil {
+			return name, err
+		}
+		for _, collector := range c.Collectors {
+			attributes := collectorAttributes(collector)
+			if mapContains(attributes, selector) {
+				ids = append(ids, collector.GetId())
+			}
+		}
+	} else {
+		for _, collector := range c.Collectors {
+			if collector.GetId() == name {
+				return name, nil
+			}
+			if collector.GetName() == name {
+				ids = append(ids, collector.GetId())
+			}
+		}
+	}
+
+	switch len(ids) {
+	case 1:
+		return ids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find collector with ID, name or attributes: %s", name)
+	default:
+		slices.Sort(ids)
+		return name, errors.Errorf("Found %d collectors matching %s: %s", len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+// CheckMatchers checks that the pipeline matchers refer to attributes that exist on at least
+// one collector, the matchers are returned as-is
+func (c *FleetManagementClient) CheckMatchers(matchers []string) ([]string, error) {
+	if err := c.getCollectors(); err != nil {
+		return nil, err
+	}
+
+	known := map[string]bool{}
+	for _, collector := range c.Collectors {
+		for name := range collectorAttributes(collector) {
+			known[name] = true
+		}
+	}
+
+	unknown := []string{}
+	for _, matcher := range matchers {
+		m := pipelineMatcherName.FindStringSubmatch(matcher)
+		if m == nil {
+			return nil, errors.Errorf("Invalid pipeline matcher: %s", matcher)
+		}
+		if !known[m[1]] {
+			unknown = append(unknown, m[1])
+		}
+	}
+	if len(unknown) != 0 {
+		return nil, errors.Errorf("Pipeline matchers refer to attributes no collector has: %s", strings.Join(unknown, ", "))
+	}
+	return matchers, nil
+}
+
+// collectorAttributes returns the attributes of a collector, remote attributes take precedence
+// over the attributes the collector reports itself
+func collectorAttributes(collector *collectorv1.Collector) map[string]string {
+	attributes := map[string]string{}
+	maps.Copy(attributes, collector.GetAttributes())
+	maps.Copy(attributes, collector.GetLocalAttributes())
+	maps.Copy(attributes, collector.GetRemoteAttributes())
+	return attributes
+}
+
+func parseCollectorSelector(selector string) (map[string]string, error) {
+	terms := map[string]string{}
+	for term := range strings.SplitSeq(selector, ",") {
+		key, value, found := strings.Cut(strings.TrimSpace(term), "=")
+		if !found || key == "" {
+			return nil, errors.Errorf("Invalid collector selector %s: term %s must be in the form <name>=<value>", selector, term)
+		}
+		terms[key] = strings.Trim(value, `"`)
+	}
+	return terms, nil
+}
+
+// mapContains returns true if m has all keys of sub with the same value
+func mapContains(m, sub map[string]string) bool {
+	for k, v := range sub {
+		if value, ok := m[k]; !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	"github.com/google/go-cmp/cmp"
+	collectorv1 "github.com/grafana/fleet-management-api/api/gen/proto/go/collector/v1"
+	"github.com/grafana/fleet-management-api/api/gen/proto/go/collector/v1/collectorv1connect"
+
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/meta"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+type fakeCollectorService struct {
+	collectorv1connect.UnimplementedCollectorServiceHandler
+	t *testing.T
+}
+
+func (s *fakeCollectorService) ListCollectors(_ context.Context, req *connect.Request[collectorv1.ListCollectorsRequest]) (*connect.Response[collectorv1.Collectors], error) {
+	if got := req.Header().Get("Authorization"); got != "Basic dXNlcjp0b2tlbg==" {
+		s.t.Errorf("unexpected Authorization header: %q", got)
+	}
+	return connect.NewResponse(&collectorv1.Collectors{
+		Collectors: []*collectorv1.Collector{
+			{Id: "web-1-id", Name: "web-1", LocalAttributes: map[string]string{"env": "prod", "os": "linux"}},
+			{Id: "web-2-id", Name: "web-2", LocalAttributes: map[string]string{"env": "prod", "os": "linux"}, RemoteAttributes: map[string]string{"team": "payments"}},
+			{Id: "dev-id", Name: "dev", LocalAttributes: map[string]string{"env": "dev", "os": "linux"}},
+		},
+	}), nil
+}
+
+func TestFleetManagementProcess(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle(collectorv1connect.NewCollectorServiceHandler(&fakeCollectorService{t: t}))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cases := map[string]struct {
+		reason string
+		kind   string
+		obj    string
+		path   string
+		want   any
+		err    bool
+	}{
+		"CollectorByName": {
+			reason: "A collector name should resolve to its ID",
+			kind:   "Collector",
+			obj:    `"metadata": {"annotations": {"crossplane.io/external-name": "dev"}}`,
+			want:   "dev-id",
+		},
+		"CollectorByAttributes": {
+			reason: "Attributes should resolve to the ID of the only matching collector",
+			kind:   "Collector",
+			obj:    `"metadata": {"annotations": {"crossplane.io/external-name": "env=prod,team=payments"}}`,
+			want:   "web-2-id",
+		},
+		"CollectorAmbiguous": {
+			reason: "Attributes matching several collectors should return an error",
+			kind:   "Collector",
+			obj:    `"metadata": {"annotations": {"crossplane.io/external-name": "env=prod"}}`,
+			err:    true,
+		},
+		"PipelineMatchers": {
+			reason: "Matchers on known attributes should be kept as-is",
+			kind:   "Pipeline",
+			obj:    `"spec": {"forProvider": {"matchers": ["env=\"prod\"", "team!~\"pay.*\""]}}`,
+			path:   "spec.forProvider.matchers",
+			want:   []any{`env="prod"`, `team!~"pay.*"`},
+		},
+		"PipelineUnknownAttribute": {
+			reason: "Matchers on attributes no collector has should return an error",
+			kind:   "Pipeline",
+			obj:    `"spec": {"forProvider": {"matchers": ["environment=\"prod\""]}}`,
+			err:    true,
+		},
+	}
+
+	c := NewFleetManagementClient(clients.NewFleetManagementClient("user:token", srv.URL))
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "fleetmanagement.grafana.crossplane.io/v1alpha1", "kind": "` + tc.kind + `", ` + tc.obj + `}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			var got any = meta.GetExternalName(desired.Resource)
+			if tc.path != "" {
+				got, err = desired.Resource.GetValue(tc.path)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -3,7 +3,7 @@ package main
 import (
 	"context"
 
-	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
 
 	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
 	"github.com/crossplane/function-sdk-go/errors"
@@ -27,10 +27,16 @@ type Function struct {
 func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest) (*fnv1.RunFunctionResponse, error) {
 	f.log.Info("Running function", "grafana-data", req.GetMeta().GetTag())
 
-	clientMap := make(map[string]*clients.Client)
+	resolverMap := make(map[string]map[string]resolver)
 
 	rsp := response.To(req, response.DefaultTTL)
 
+	in := &v1beta1.Input{}
+	if err := request.GetInput(req, in); err != nil {
+		response.Fatal(rsp, errors.Wrapf(err, "cannot get Function input from %T", req))
+		return rsp, nil
+	}
+
 	compositeResource, err := request.GetObservedCompositeResource(req)
 	if err != nil {
 		response.Fatal(rsp, errors.Wrapf(err, "cannot get composite resource from %T", req))
@@ -47,6 +53,22 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 		return rsp, nil
 	}
 
+	// plugin versions recorded in a previous invocation are kept unless the desired resource sets them
+	if err := keepPluginVersionAnnotations(req, desiredComposed); err != nil {
+		response.Fatal(rsp, errors.Wrapf(err, "cannot get observed composed resources from %T", req))
+		return rsp, nil
+	}
+
+	// team names are checked as written, before they resolve to IDs
+	if in.Teams.CheckExternalGroups {
+		for _, err := range checkTeamExternalGroups(desiredComposed) {
+			response.Warning(rsp, err).TargetCompositeAndClaim()
+		}
+	}
+
+	// alerting resources may refer to each other before they exist in Grafana
+	composedAlerting := composedAlertingNames(desiredComposed)
+
 	for _, desired := range desiredComposed {
 		gvk := desired.Resource.GroupVersionKind()
 
@@ -57,52 +79,37 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 			return rsp, nil
 		}
 
-		if _, ok := clientMap[providerConfigName]; !ok {
-			cf := clientsFetcher{
-				req:                req,
-				rsp:                rsp,
-				providerConfigName: providerConfigName,
-			}
-			cs, err := cf.getClients()
-			if err != nil {
-				response.Fatal(rsp, errors.Errorf("cannot fetch client: %q", err))
-				return rsp, nil
-			}
-			if cs == nil {
-				// grabbing the providerConfig and secret for setting up the clients might need a few roundtrips
-				continue
-			}
-			clientMap[providerConfigName] = cs
+		resolvers, err := getResolvers(req, rsp, resolverMap, providerConfigName, in, composedAlerting)
+		if err != nil {
+			response.Fatal(rsp, errors.Errorf("cannot fetch client: %q", err))
+			return rsp, nil
+		}
+		if resolvers == nil {
+			// grabbing the providerConfig and secret for setting up the clients might need a few roundtrips
+			continue
 		}
 
-		switch gvk.Group {
-		case "oncall.grafana.crossplane.io":
-			if err := NewOnCallClient(clientMap[providerConfigName].OnCallClient).Process(desired); err != nil {
-				response.Warning(rsp, err).TargetCompositeAndClaim()
-			}
-
-		case "sm.grafana.crossplane.io":
-			if err := NewSMClient(clientMap[providerConfigName].SMAPI).Process(desired); err != nil {
-				response.Warning(rsp, err).TargetCompositeAndClaim()
-			}
-
-		case "oss.grafana.crossplane.io":
-			if err := NewGrafanaClient(clientMap[providerConfigName].GrafanaAPI).Process(desired); err != nil {
-				response.Warning(rsp, err).TargetCompositeAndClaim()
-			}
-
-		case "enterprise.grafana.crossplane.io":
-			if err := NewGrafanaClient(clientMap[providerConfigName].GrafanaAPI).Process(desired); err != nil {
-				response.Warning(rsp, err).TargetCompositeAndClaim()
-			}
+		// inline expressions are expanded before the fields of the kind are resolved
+		if err := expandExpressions(desired, expressionLookups(resolvers)); err != nil {
+			response.Warning(rsp, err).TargetCompositeAndClaim()
+		}
 
-		case "alerting.grafana.crossplane.io":
-			if err := NewAlertingClient(clientMap[providerConfigName].GrafanaAPI, clientMap[providerConfigName].OnCallClient).Process(desired); err != nil {
-				response.Warning(rsp, err).TargetCompositeAndClaim()
-			}
+		r, ok := resolvers[gvk.Group]
+		if !ok {
+			continue
+		}
+		if err := r.Process(desired); err != nil {
+			response.Warning(rsp, err).TargetCompositeAndClaim()
 		}
 	}
 
+	if err := publishInputFacts(req, rsp, in, func(providerConfigName string) (map[string]resolver, error) {
+		return getResolvers(req, rsp, resolverMap, providerConfigName, in, composedAlerting)
+	}); err != nil {
+		response.Fatal(rsp, err)
+		return rsp, nil
+	}
+
 	if err := response.SetDesiredComposedResources(rsp, desiredComposed); err != nil {
 		response.Fatal(rsp, errors.Wrapf(err, "cannot set desired composed resources in %T", rsp))
 		return rsp, nil
@@ -121,6 +128,27 @@ func (f *Function) RunFunction(_ context.Context, req *fnv1.RunFunctionRequest)
 	return rsp, nil
 }
 
+// getResolvers returns the resolvers for a providerConfig, creating them on first use, it returns
+// nil while the providerConfig and its secret are still being requested
+func getResolvers(req *fnv1.RunFunctionRequest, rsp *fnv1.RunFunctionResponse, resolverMap map[string]map[string]resolver, providerConfigName string, in *v1beta1.Input, composedAlerting map[string][]string) (map[string]resolver, error) {
+	if resolvers, ok := resolverMap[providerConfigName]; ok {
+		return resolvers, nil
+	}
+
+	cf := clientsFetcher{
+		req:                req,
+		rsp:                rsp,
+		providerConfigName: providerConfigName,
+	}
+	cs, err := cf.getClients()
+	if err != nil || cs == nil {
+		return nil, err
+	}
+
+	resolverMap[providerConfigName] = newResolvers(cs, in, composedAlerting)
+	return resolverMap[providerConfigName], nil
+}
+
 // hasWarningsOrFatal checks if the response contains any non-normal severity results
 func hasWarningsOrFatal(rsp *fnv1.RunFunctionResponse) bool {
 	for _, result := range rsp.GetResults() {
@@ -151,3 +179,50 @@ func replacePath[V, W any](desired *resource.DesiredComposed, path string, fn fu
 
 	return nil
 }
+
+// replaceValue replaces an optional value in place, an optional reference that cannot be resolved
+// is removed and a fallback replaces a reference that cannot be resolved
+func replaceValue[V any](val **V, fn func(V) (V, error)) error {
+	if *val == nil {
+		return nil
+	}
+
+	ref := reference{}
+	if s, ok := any(**val).(string); ok {
+		ref = parseReference(s)
+		if err := convertValue(ref.Name, *val); err != nil {
+			return errors.Wrapf(err, "cannot convert reference %s", ref.Name)
+		}
+	}
+
+	newVal, err := fn(**val)
+	if err != nil {
+		switch {
+		case ref.Fallback != nil:
+			if newVal, err = fallbackValue[V](*ref.Fallback); err != nil {
+				return err
+			}
+		case ref.Optional:
+			*val = nil
+			return nil
+		default:
+			return err
+		}
+	}
+	*val = &newVal
+	return nil
+}
+
+// dedupe removes duplicate values while keeping the order of first occurrence
+func dedupe[T comparable](values []T) []T {
+	seen := make(map[T]struct{}, len(values))
+	newValues := make([]T, 0, len(values))
+	for _, v := range values {
+		if _, ok := seen[v]; ok {
+			continue
+		}
+		seen[v] = struct{}{}
+		newValues = append(newValues, v)
+	}
+	return newValues
+}
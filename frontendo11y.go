@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// FrontendO11yClient is a client with convenience methods
+type FrontendO11yClient struct {
+	Client *clients.FrontendO11yClient
+	// Apps holds the apps by stack slug
+	Apps map[string][]clients.FrontendO11yApp
+
+	// Cloud is optional, it looks up the stack of an app
+	Cloud *CloudClient
+}
+
+// NewFrontendO11yClient returns a client with convenience methods
+func NewFrontendO11yClient(client *clients.FrontendO11yClient, cloud *CloudClient) *FrontendO11yClient {
+	return &FrontendO11yClient{
+		Client: client,
+		Apps:   map[string][]clients.FrontendO11yApp{},
+		Cloud:  cloud,
+	}
+}
+
+func (c *FrontendO11yClient) getApps(slug string) ([]clients.FrontendO11yApp, error) {
+	if c.Client == nil || c.Cloud == nil {
+		return nil, errors.New("Frontend Observability lookups require frontend_o11y_api_access_token or cloud_access_policy_token credentials")
+	}
+
+	stack, err := c.Cloud.FindStack(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	// only populate the list if the list is empty
+	if apps, ok := c.Apps[stack.Slug]; ok {
+		return apps, nil
+	}
+
+	createdAt, err := time.Parse(time.RFC3339, stack.CreatedAt)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to parse creation date of stack %s", stack.Slug)
+	}
+
+	ctx := context.Background()
+	baseURL := c.Client.FaroEndpointURL(stack.RegionSlug, createdAt)
+	apps, err := c.Client.GetApps(ctx, baseURL, int64(stack.Id))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list Frontend Observability apps of stack %s", stack.Slug)
+	}
+
+	c.Apps[stack.Slug] = apps
+	return apps, nil
+}
+
+// Process processes fields of different kinds
+func (c *FrontendO11yClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	if gvk.Kind == "App" && c.Cloud != nil {
+		path := "spec.forProvider.stackId"
+		return replaceReferencePath(desired, path, c.Cloud.GetStackNumericID)
+	}
+	return nil
+}
+
+// FindApp looks up an app of a stack by ID or name
+func (c *FrontendO11yClient) FindApp(slug, name string) (*clients.FrontendO11yApp, error) {
+	apps, err := c.getApps(slug)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []int{}
+	for i, app := range apps {
+		if strconv.Itoa(int(app.ID)) == name {
+			return &apps[i], nil
+		}
+		if app.Name == name {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return &apps[matches[0]], nil
+	case 0:
+		return nil, errors.Errorf("Could not find Frontend Observability app with ID or name %s in stack %s", name, slug)
+	default:
+		return nil, errors.Errorf("Found %d Frontend Observability apps named %s in stack %s", len(matches), name, slug)
+	}
+}
+
+// GetAppFacts looks up an app of a stack by ID or name and returns its ID, key and collector endpoint
+func (c *FrontendO11yClient) GetAppFacts(slug, name string) (map[string]string, error) {
+	app, err := c.FindApp(slug, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]string{
+		"appId":   strconv.Itoa(int(app.ID)),
+		"appName": app.Name,
+		"appKey":  app.Key,
+		// the collector endpoint includes the app key, like the collector_endpoint of the TF provider
+		"collectorEndpoint": app.CollectEndpointURL + "/" + app.Key,
+	}, nil
+}
@@ -2,13 +2,16 @@ package main
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/go-openapi/runtime"
 	"github.com/grafana/crossplane-provider-grafana/v2/apis/cluster/oss/v1alpha1"
 	"github.com/grafana/grafana-openapi-client-go/client"
 	"github.com/grafana/grafana-openapi-client-go/client/access_control"
 	"github.com/grafana/grafana-openapi-client-go/client/org"
+	"github.com/grafana/grafana-openapi-client-go/client/search"
 	"github.com/grafana/grafana-openapi-client-go/client/service_accounts"
 	"github.com/grafana/grafana-openapi-client-go/client/teams"
 	"github.com/grafana/grafana-openapi-client-go/models"
@@ -17,9 +20,23 @@ import (
 	"github.com/crossplane/function-sdk-go/resource"
 )
 
+const (
+	// exprDataSourceUID is the UID of the server side expressions data source, "-100" is its legacy UID
+	exprDataSourceUID       = "__expr__"
+	legacyExprDataSourceUID = "-100"
+)
+
 // GrafanaClient is a client with convenience methods
 type GrafanaClient struct {
-	Client *client.GrafanaHTTPAPI
+	Client      *client.GrafanaHTTPAPI
+	DataSources models.DataSourceList
+	Folders     models.HitList
+	Dashboards  models.HitList
+
+	LibraryPanels   []*models.LibraryElementDTO
+	Teams           []*models.TeamDTO
+	Users           []*models.OrgUserDTO
+	ServiceAccounts []*models.ServiceAccountDTO
 }
 
 // NewGrafanaClient returns a client with convenience methods
@@ -33,53 +50,85 @@ func NewGrafanaClient(client *client.GrafanaHTTPAPI) *GrafanaClient {
 func (c *GrafanaClient) Process(desired *resource.DesiredComposed) error {
 	gvk := desired.Resource.GroupVersionKind()
 	switch gvk.Kind {
+	case "Annotation":
+		path := "spec.forProvider.dashboardUid"
+		return replaceReferencePath(desired, path, c.GetDashboardUID)
+
+	case "OrganizationPreferences":
+		path := "spec.forProvider.homeDashboardUid"
+		return replaceReferencePath(desired, path, c.GetDashboardUID)
+
+	case "Team":
+		path := "spec.forProvider.preferences"
+		return replacePath(desired, path, c.GetPreferences)
+
+	case "TeamExternalGroup":
+		path := pathTeamID
+		return replaceReferencePath(desired, path, c.GetTeam)
+
+	case "Dashboard":
+		path := "spec.forProvider.configJson"
+		return replacePath(desired, path, c.GetDashboardConfigJSON)
+
+	case "LibraryPanel":
+		path := "spec.forProvider.folderUid"
+		return replaceReferencePath(desired, path, c.GetFolderUID)
+
+	case "Playlist":
+		path := "spec.forProvider.item"
+		return replacePath(desired, path, c.GetPlaylistItems)
+
+	case "Report":
+		path := "spec.forProvider.dashboards"
+		return replacePath(desired, path, c.GetReportDashboards)
+
 	case "FolderPermission":
 		path := "spec.forProvider.permissions"
 		return replacePath(desired, path, c.GetTeamIDForFolderPermissions)
 
 	case "RoleAssignment":
 		path := "spec.forProvider.roleUid"
-		err := replacePath(desired, path, c.GetRoleUID)
+		err := replaceReferencePath(desired, path, c.GetRoleUID)
 		if err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.serviceAccounts"
-		err = replacePath(desired, path, c.GetServiceAccounts)
+		err = replaceReferencePath(desired, path, c.GetServiceAccounts)
 		if err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.users"
-		err = replacePath(desired, path, c.GetUsers)
+		err = replaceReferencePath(desired, path, c.GetUsers)
 		if err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.teams"
-		return replacePath(desired, path, c.GetTeams)
+		return replaceReferencePath(desired, path, c.GetTeams)
 
 	case "RoleAssignmentItem":
 		path := "spec.forProvider.roleUid"
-		err := replacePath(desired, path, c.GetRoleUID)
+		err := replaceReferencePath(desired, path, c.GetRoleUID)
 		if err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.serviceAccountId"
-		err = replacePath(desired, path, c.GetServiceAccount)
+		err = replaceReferencePath(desired, path, c.GetServiceAccount)
 		if err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.userId"
-		err = replacePath(desired, path, c.GetUser)
+		err = replaceReferencePath(desired, path, c.GetUser)
 		if err != nil {
 			return err
 		}
 
 		path = pathTeamID
-		return replacePath(desired, path, c.GetTeam)
+		return replaceReferencePath(desired, path, c.GetTeam)
 	}
 	return nil
 }
@@ -89,80 +138,109 @@ func (c *GrafanaClient) GetTeamIDForFolderPermissions(permissions []v1alpha1.Fol
 	newPermissions := make([]v1alpha1.FolderPermissionPermissionsParameters, 0, len(permissions))
 	for _, p := range permissions {
 		if p.TeamID != nil {
-			teamID, err := c.GetTeam(*p.TeamID)
-			if err != nil {
-				// return nil, err
-				fmt.Println(err) // TODO: handle errors better
+			if err := replaceValue(&p.TeamID, c.GetTeam); err != nil {
+				return nil, err
+			}
+			// an optional team that cannot be resolved removes its permission
+			if p.TeamID == nil {
+				continue
 			}
-			p.TeamID = &teamID
 		}
 		newPermissions = append(newPermissions, p)
 	}
 	return newPermissions, nil
 }
 
-// GetUsers looks up users and returns the IDs
+// GetUsers looks up users and returns the IDs, selectors expand to every matching user
 func (c *GrafanaClient) GetUsers(names []string) ([]string, error) {
-	newVal := []string{}
-	for _, id := range names {
-		userID, err := c.GetUser(id)
-		if err != nil {
-			return nil, err
-		}
-		newVal = append(newVal, userID)
-	}
-	return newVal, nil
+	return lookupNames(names, c.GetUser, c.SelectUsers)
 }
 
-// GetTeams looks up teams and returns the IDs
+// GetTeams looks up teams and returns the IDs, selectors expand to every matching team
 func (c *GrafanaClient) GetTeams(names []string) ([]string, error) {
-	newVal := []string{}
-	for _, id := range names {
-		teamID, err := c.GetTeam(id)
-		if err != nil {
-			return nil, err
-		}
-		newVal = append(newVal, teamID)
-	}
-	return newVal, nil
+	return lookupNames(names, c.GetTeam, c.SelectTeams)
 }
 
-// GetServiceAccounts looks up serviceAccounts and returns the IDs
+// GetServiceAccounts looks up serviceAccounts and returns the IDs, selectors expand to every
+// matching service account
 func (c *GrafanaClient) GetServiceAccounts(names []string) ([]string, error) {
-	newVal := []string{}
-	for _, id := range names {
-		serviceAccountID, err := c.GetServiceAccount(id)
-		if err != nil {
-			return nil, err
-		}
-		newVal = append(newVal, serviceAccountID)
-	}
-	return newVal, nil
+	return lookupNames(names, c.GetServiceAccount, c.SelectServiceAccounts)
 }
 
 // GetTeam will return the ID for a team name
 func (c *GrafanaClient) GetTeam(name string) (string, error) {
-	_, err := c.Client.Teams.GetTeamByID(name)
-	if err == nil {
-		return name, nil
+	team, err := c.FindTeam(name)
+	if err != nil {
+		return name, err
 	}
-	if respErr, ok := err.(runtime.ClientResponseStatus); !ok || !respErr.IsCode(404) {
-		return "", err
+	return strconv.FormatInt(team.ID, 10), nil
+}
+
+// FindTeam will return the team for a team ID or name
+func (c *GrafanaClient) FindTeam(name string) (*models.TeamDTO, error) {
+	if _, err := strconv.ParseInt(name, 10, 64); err == nil {
+		resp, err := c.Client.Teams.GetTeamByID(name)
+		if err == nil {
+			return resp.GetPayload(), nil
+		}
+		if respErr, ok := err.(runtime.ClientResponseStatus); !ok || !respErr.IsCode(404) {
+			return nil, err
+		}
+	}
+
+	// teams that were listed before, for a selector or an earlier miss, need no search
+	if c.Teams != nil {
+		return c.findListedTeam(name)
 	}
 
 	respBySearch, err := c.Client.Teams.SearchTeams(
 		teams.NewSearchTeamsParams().WithName(&name),
 	)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 	for _, r := range respBySearch.GetPayload().Teams {
 		if r.Name == name {
-			return strconv.FormatInt(r.ID, 10), nil
+			return r, nil
+		}
+	}
+
+	// the search only finds teams by part of their name, suggest teams for typos from the full list
+	if err := c.getTeams(); err != nil {
+		return nil, errors.Errorf("Could not find ID for team: %s", name)
+	}
+	return c.findListedTeam(name)
+}
+
+// findListedTeam looks up a team by name in the listed teams, a miss suggests close names
+func (c *GrafanaClient) findListedTeam(name string) (*models.TeamDTO, error) {
+	names := make([]string, 0, len(c.Teams))
+	for _, t := range c.Teams {
+		if t.Name == name {
+			return t, nil
 		}
+		names = append(names, t.Name)
+	}
+	return nil, errors.Errorf("Could not find ID for team: %s%s", name, didYouMean(name, names))
+}
+
+// GetTeamMembers will return the members of a team by team ID or name, sorted by login
+func (c *GrafanaClient) GetTeamMembers(name string) ([]*models.TeamMemberDTO, error) {
+	team, err := c.FindTeam(name)
+	if err != nil {
+		return nil, err
 	}
 
-	return name, errors.Errorf("Could not find ID for team: %s", name)
+	resp, err := c.Client.Teams.GetTeamMembers(strconv.FormatInt(team.ID, 10))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not list members for team: %s", name)
+	}
+
+	members := resp.GetPayload()
+	slices.SortFunc(members, func(a, b *models.TeamMemberDTO) int {
+		return strings.Compare(a.Login, b.Login)
+	})
+	return members, nil
 }
 
 // GetRoleUID will return the UID for a role name
@@ -227,3 +305,131 @@ func (c *GrafanaClient) GetUser(name string) (string, error) {
 
 	return name, errors.Errorf("Could not find ID for user: %s", name)
 }
+
+func (c *GrafanaClient) getDataSources() error {
+	// only populate the list if the list is empty
+	if len(c.DataSources) != 0 {
+		return nil
+	}
+
+	resp, err := c.Client.Datasources.GetDataSources()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list data sources")
+	}
+	c.DataSources = resp.GetPayload()
+	return nil
+}
+
+// FindDataSource will return the data source for a data source UID or name
+func (c *GrafanaClient) FindDataSource(name string) (*models.DataSourceListItemDTO, error) {
+	if err := c.getDataSources(); err != nil {
+		return nil, err
+	}
+
+	idx := slices.IndexFunc(c.DataSources, func(ds *models.DataSourceListItemDTO) bool {
+		return ds.UID == name
+	})
+	if idx == -1 {
+		idx = slices.IndexFunc(c.DataSources, func(ds *models.DataSourceListItemDTO) bool {
+			return ds.Name == name
+		})
+	}
+	if idx != -1 {
+		return c.DataSources[idx], nil
+	}
+
+	return nil, errors.Errorf("Could not find data source with UID or name: %s", name)
+}
+
+// GetDataSourceUID will return the UID for a data source name, the expressions data source is returned as-is
+func (c *GrafanaClient) GetDataSourceUID(name string) (string, error) {
+	if name == exprDataSourceUID || name == legacyExprDataSourceUID {
+		return name, nil
+	}
+
+	ds, err := c.FindDataSource(name)
+	if err != nil {
+		return name, err
+	}
+	return ds.UID, nil
+}
+
+func (c *GrafanaClient) getFolders() error {
+	// only populate the list if the list is empty
+	if len(c.Folders) != 0 {
+		return nil
+	}
+
+	allFolders := models.HitList{}
+	searchType := "dash-folder"
+	limit := int64(1000)
+	page := int64(1)
+	for {
+		params := search.NewSearchParams().WithType(&searchType).WithLimit(&limit).WithPage(&page)
+		resp, err := c.Client.Search.Search(params)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list folders")
+		}
+		allFolders = append(allFolders, resp.GetPayload()...)
+
+		if int64(len(resp.GetPayload())) < limit {
+			break
+		}
+		page++
+	}
+	c.Folders = allFolders
+	return nil
+}
+
+// GetFolderUID will return the UID for a folder UID, title or path of titles separated by "/"
+func (c *GrafanaClient) GetFolderUID(name string) (string, error) {
+	if err := c.getFolders(); err != nil {
+		return name, err
+	}
+
+	if slices.ContainsFunc(c.Folders, func(f *models.Hit) bool {
+		return f.UID == name
+	}) {
+		return name, nil
+	}
+
+	if strings.Contains(name, "/") {
+		return c.getFolderUIDByPath(name)
+	}
+
+	matches := []string{}
+	for _, f := range c.Folders {
+		if f.Title == name {
+			matches = append(matches, f.UID)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find folder with UID or title: %s", name)
+	default:
+		return name, errors.Errorf("Found %d folders with title %s, use the folder path instead: %s", len(matches), name, strings.Join(matches, ", "))
+	}
+}
+
+// getFolderUIDByPath walks down the nested folders along a path of titles
+func (c *GrafanaClient) getFolderUIDByPath(path string) (string, error) {
+	parentUID := ""
+	for title := range strings.SplitSeq(strings.Trim(path, "/"), "/") {
+		matches := []string{}
+		for _, f := range c.Folders {
+			if f.Title == title && f.FolderUID == parentUID {
+				matches = append(matches, f.UID)
+			}
+		}
+		if len(matches) == 0 {
+			return path, errors.Errorf("Could not find folder %s in path: %s", title, path)
+		}
+		if len(matches) > 1 {
+			return path, errors.Errorf("Found %d folders with title %s in path: %s", len(matches), title, path)
+		}
+		parentUID = matches[0]
+	}
+	return parentUID, nil
+}
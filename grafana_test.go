@@ -0,0 +1,385 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/go-cmp/cmp"
+	goapi "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestGrafanaDashboardUID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("type") {
+		case "dash-folder":
+			writeJSON(t, w, models.HitList{
+				{UID: "team-a", Title: "Team A"},
+				{UID: "team-b", Title: "Team B"},
+			})
+		case "dash-db":
+			writeJSON(t, w, models.HitList{
+				{UID: "home-uid", Title: "Home", FolderUID: "team-a"},
+				{UID: "overview-a", Title: "Overview", FolderUID: "team-a"},
+				{UID: "overview-b", Title: "Overview", FolderUID: "team-b"},
+			})
+		default:
+			t.Errorf("unexpected search type: %q", r.URL.Query().Get("type"))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+
+	cases := map[string]struct {
+		reason string
+		obj    string
+		path   string
+		want   any
+		err    bool
+	}{
+		"OrganizationPreferences": {
+			reason: "A dashboard title should resolve to its UID",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "OrganizationPreferences", "spec": {"forProvider": {"homeDashboardUid": "Home"}}}`,
+			path:   "spec.forProvider.homeDashboardUid",
+			want:   "home-uid",
+		},
+		"TeamPreferences": {
+			reason: "A folder path and title should resolve to the dashboard in that folder",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"preferences": [{"homeDashboardUid": "Team B/Overview"}]}}}`,
+			path:   "spec.forProvider.preferences",
+			want:   []any{map[string]any{"homeDashboardUid": "overview-b"}},
+		},
+		"Playlist": {
+			reason: "Only items that refer to a dashboard by UID should be resolved",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Playlist", "spec": {"forProvider": {"item": [{"type": "dashboard_by_uid", "value": "Home"}, {"type": "dashboard_by_tag", "value": "Home"}]}}}`,
+			path:   "spec.forProvider.item",
+			want: []any{
+				map[string]any{"type": "dashboard_by_uid", "value": "home-uid"},
+				map[string]any{"type": "dashboard_by_tag", "value": "Home"},
+			},
+		},
+		"Report": {
+			reason: "An existing UID should be kept",
+			obj:    `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "Report", "spec": {"forProvider": {"dashboards": [{"uid": "overview-a"}]}}}`,
+			path:   "spec.forProvider.dashboards",
+			want:   []any{map[string]any{"uid": "overview-a"}},
+		},
+		"Ambiguous": {
+			reason: "A title used by several dashboards should return an error",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Annotation", "spec": {"forProvider": {"dashboardUid": "Overview"}}}`,
+			err:    true,
+		},
+	}
+
+	c := NewGrafanaClient(grafana)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			if err := desired.Resource.UnmarshalJSON([]byte(tc.obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGrafanaLibraryPanel(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.HitList{
+			{UID: "team-a", Title: "Team A"},
+			{UID: "team-b", Title: "Team B"},
+		})
+	})
+	mux.HandleFunc("/api/library-elements", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("kind"); got != "1" {
+			t.Errorf("unexpected library element kind: %q", got)
+		}
+		writeJSON(t, w, models.LibraryElementSearchResponse{
+			Result: &models.LibraryElementSearchResult{
+				Elements: []*models.LibraryElementDTO{
+					{UID: "latency-uid", Name: "Latency", FolderUID: "team-a"},
+					{UID: "errors-a", Name: "Errors", FolderUID: "team-a"},
+					{UID: "errors-b", Name: "Errors", FolderUID: "team-b"},
+				},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+
+	cases := map[string]struct {
+		reason string
+		obj    string
+		path   string
+		want   any
+		err    bool
+	}{
+		"LibraryPanelFolder": {
+			reason: "The folder title of a library panel should resolve to its UID",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "LibraryPanel", "spec": {"forProvider": {"name": "Latency", "folderUid": "Team B"}}}`,
+			path:   "spec.forProvider.folderUid",
+			want:   "team-b",
+		},
+		"DashboardLibraryPanels": {
+			reason: "Library panels referred to by name, also in collapsed rows, should get their UID",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Dashboard", "spec": {"forProvider": {"configJson": "{\"title\":\"Service\",\"panels\":[{\"id\":1,\"libraryPanel\":{\"name\":\"Latency\"}},{\"id\":2,\"type\":\"row\",\"panels\":[{\"id\":3,\"libraryPanel\":{\"name\":\"Team B/Errors\"}}]}]}"}}}`,
+			path:   "spec.forProvider.configJson",
+			want:   `{"panels":[{"id":1,"libraryPanel":{"name":"Latency","uid":"latency-uid"}},{"id":2,"panels":[{"id":3,"libraryPanel":{"name":"Team B/Errors","uid":"errors-b"}}],"type":"row"}],"title":"Service"}`,
+		},
+		"DashboardUnchanged": {
+			reason: "A dashboard without library panel names should be kept as written",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Dashboard", "spec": {"forProvider": {"configJson": "{\"title\": \"Service\", \"panels\": [{\"libraryPanel\": {\"uid\": \"errors-a\", \"name\": \"Errors\"}}]}"}}}`,
+			path:   "spec.forProvider.configJson",
+			want:   `{"title": "Service", "panels": [{"libraryPanel": {"uid": "errors-a", "name": "Errors"}}]}`,
+		},
+		"DashboardLargeNumbers": {
+			reason: "Numbers should be kept as written when a library panel is filled in",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Dashboard", "spec": {"forProvider": {"configJson": "{\"id\":9007199254740993,\"panels\":[{\"id\":1,\"libraryPanel\":{\"name\":\"Latency\"},\"threshold\":0.1}]}"}}}`,
+			path:   "spec.forProvider.configJson",
+			want:   `{"id":9007199254740993,"panels":[{"id":1,"libraryPanel":{"name":"Latency","uid":"latency-uid"},"threshold":0.1}]}`,
+		},
+		"DashboardFallbackText": {
+			reason: "Text in a dashboard that looks like a reference fallback should be kept as written",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Dashboard", "spec": {"forProvider": {"configJson": "{\"panels\": [{\"type\": \"text\", \"options\": {\"content\": \"a ?? b\"}}]}"}}}`,
+			path:   "spec.forProvider.configJson",
+			want:   `{"panels": [{"type": "text", "options": {"content": "a ?? b"}}]}`,
+		},
+		"Ambiguous": {
+			reason: "A name used by several library panels should return an error",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Dashboard", "spec": {"forProvider": {"configJson": "{\"panels\": [{\"libraryPanel\": {\"name\": \"Errors\"}}]}"}}}`,
+			err:    true,
+		},
+	}
+
+	c := NewGrafanaClient(grafana)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			if err := desired.Resource.UnmarshalJSON([]byte(tc.obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGrafanaRoleAssignmentSelectors(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/teams/search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.SearchTeamQueryResult{Teams: []*models.TeamDTO{
+			{ID: 12, Name: "team-payments-eu"},
+			{ID: 3, Name: "team-payments-us"},
+			{ID: 5, Name: "team-platform"},
+		}})
+	})
+	mux.HandleFunc("/api/org/users", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, []*models.OrgUserDTO{
+			{UserID: 2, Login: "alice", Email: "alice@example.com"},
+			{UserID: 4, Login: "bob", Email: "bob@example.com"},
+		})
+	})
+	mux.HandleFunc("/api/serviceaccounts/search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.SearchOrgServiceAccountsResult{ServiceAccounts: []*models.ServiceAccountDTO{
+			{ID: 9, Name: "ci-deploy"},
+			{ID: 7, Name: "ci-build"},
+			{ID: 8, Name: "grafana-agent"},
+		}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+
+	cases := map[string]struct {
+		reason string
+		val    string
+		path   string
+		want   any
+		err    bool
+		errMsg string
+	}{
+		"Teams": {
+			reason: "A prefix selector should expand to the sorted IDs of all matching teams",
+			val:    `{"teams": ["prefix:team-payments-"]}`,
+			path:   "spec.forProvider.teams",
+			want:   []any{"3", "12"},
+		},
+		"ServiceAccounts": {
+			reason: "A regex selector should expand to all matching service accounts",
+			val:    `{"serviceAccounts": ["regex:^ci-.*"]}`,
+			path:   "spec.forProvider.serviceAccounts",
+			want:   []any{"7", "9"},
+		},
+		"Users": {
+			reason: "Selectors should match the login or email and be deduplicated with plain names",
+			val:    `{"users": ["alice", "regex:@example\\.com$"]}`,
+			path:   "spec.forProvider.users",
+			want:   []any{"2", "4"},
+		},
+		"NoMatch": {
+			reason: "A selector that matches nothing should return an error",
+			val:    `{"teams": ["prefix:team-search-"]}`,
+			err:    true,
+		},
+		"Sorted": {
+			reason: "Plain names should be sorted with the expanded IDs",
+			val:    `{"teams": ["team-platform", "prefix:team-payments-"]}`,
+			path:   "spec.forProvider.teams",
+			want:   []any{"3", "5", "12"},
+		},
+		"LabelSelector": {
+			reason: "A label: selector should be reported as unsupported instead of being looked up as a name",
+			val:    `{"teams": ["label:tier=1"]}`,
+			err:    true,
+			errMsg: "Unsupported selector label:tier=1",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := NewGrafanaClient(grafana)
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "RoleAssignment", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				if tc.errMsg != "" && !strings.Contains(err.Error(), tc.errMsg) {
+					t.Errorf("%s\nc.Process(...): error %q does not contain %q", tc.reason, err, tc.errMsg)
+				}
+				return
+			}
+
+			got, err := desired.Resource.GetValue(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestGrafanaFindTeamListsTeamsOnce(t *testing.T) {
+	searches, listings := 0, 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/teams/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") != "" {
+			searches++
+			writeJSON(t, w, models.SearchTeamQueryResult{})
+			return
+		}
+		listings++
+		writeJSON(t, w, models.SearchTeamQueryResult{Teams: []*models.TeamDTO{
+			{ID: 3, Name: "payments"},
+			{ID: 5, Name: "platform"},
+		}})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+
+	c := NewGrafanaClient(grafana)
+	for _, name := range []string{"platfrom", "paymnts", "search"} {
+		if _, err := c.FindTeam(name); err == nil {
+			t.Errorf("c.FindTeam(%q): expected an error", name)
+		}
+	}
+	_, err = c.FindTeam("platfrom")
+	if diff := cmp.Diff("Could not find ID for team: platfrom (did you mean: platform?)", err.Error()); diff != "" {
+		t.Errorf("c.FindTeam(...): -want, +got:\n%s", diff)
+	}
+	team, err := c.FindTeam("payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(int64(3), team.ID); diff != "" {
+		t.Errorf("c.FindTeam(...): -want, +got:\n%s", diff)
+	}
+
+	if searches != 1 || listings != 1 {
+		t.Errorf("Teams should be searched and listed once, got %d searches and %d listings", searches, listings)
+	}
+}
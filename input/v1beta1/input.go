@@ -18,4 +18,117 @@ import (
 type Input struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	// Channels maps chat channel names to IDs for chat apps that cannot be
+	// listed through the OnCall API.
+	// +optional
+	Channels Channels `json:"channels,omitempty"`
+
+	// Alerting configures the lookups for alerting resources.
+	// +optional
+	Alerting Alerting `json:"alerting,omitempty"`
+
+	// Teams configures the checks for teams.
+	// +optional
+	Teams Teams `json:"teams,omitempty"`
+
+	// Plugins configures the version resolution of plugin installations.
+	// +optional
+	Plugins Plugins `json:"plugins,omitempty"`
+
+	// Stack looks up a Grafana Cloud stack and publishes its endpoints and
+	// instance IDs.
+	// +optional
+	Stack *Stack `json:"stack,omitempty"`
+
+	// FrontendApps looks up Frontend Observability apps and publishes their
+	// collector endpoint and app key.
+	// +optional
+	FrontendApps *FrontendApps `json:"frontendApps,omitempty"`
+}
+
+// Channels maps channel names to channel IDs per chat app.
+type Channels struct {
+	// Telegram maps Telegram channel names to chat IDs.
+	// +optional
+	Telegram map[string]string `json:"telegram,omitempty"`
+
+	// MSTeams maps Microsoft Teams channel names to channel IDs.
+	// +optional
+	MSTeams map[string]string `json:"msteams,omitempty"`
+}
+
+// Alerting configures the lookups for alerting resources.
+type Alerting struct {
+	// CanonicalNames replaces the contact point and mute timing references of
+	// notification policies, the contact points in the notification settings
+	// of RuleGroup rules and the contact-point: labels of SLOs with the name
+	// known to Grafana. References then also match names case-insensitively
+	// and contact point UIDs.
+	// +optional
+	CanonicalNames bool `json:"canonicalNames,omitempty"`
+}
+
+// Teams configures the checks for teams.
+type Teams struct {
+	// CheckExternalGroups warns when a team maps an external group more than
+	// once, or when an external group is mapped to several teams.
+	// +optional
+	CheckExternalGroups bool `json:"checkExternalGroups,omitempty"`
+}
+
+// Plugins configures the version resolution of plugin installations.
+type Plugins struct {
+	// VersionCacheTTL is how long a resolved plugin version is kept before
+	// the plugin catalog is checked for a newer version. Defaults to 24h.
+	// +optional
+	VersionCacheTTL *metav1.Duration `json:"versionCacheTTL,omitempty"`
+}
+
+// Stack configures where the connection facts of a Grafana Cloud stack are
+// published.
+type Stack struct {
+	// Slug of the stack.
+	Slug string `json:"slug"`
+
+	// ProviderConfigName is the name of the providerConfig with the Grafana
+	// Cloud credentials.
+	ProviderConfigName string `json:"providerConfigName"`
+
+	// StatusField is the field path in the desired composite resource to
+	// write the facts to, for example status.stack.
+	// +optional
+	StatusField string `json:"statusField,omitempty"`
+
+	// ConnectionDetails writes the facts to the connection details of the
+	// composite resource.
+	// +optional
+	ConnectionDetails bool `json:"connectionDetails,omitempty"`
+
+	// ContextKey is the pipeline context key to write the facts to.
+	// +optional
+	ContextKey string `json:"contextKey,omitempty"`
+}
+
+// FrontendApps configures which Frontend Observability apps are looked up and
+// where their facts are published.
+type FrontendApps struct {
+	// StackSlug is the slug of the stack the apps belong to.
+	StackSlug string `json:"stackSlug"`
+
+	// ProviderConfigName is the name of the providerConfig with the Grafana
+	// Cloud and Frontend Observability credentials.
+	ProviderConfigName string `json:"providerConfigName"`
+
+	// Names of the apps to look up, an app ID is also accepted.
+	Names []string `json:"names"`
+
+	// StatusField is the field path in the desired composite resource to
+	// write the facts to, for example status.frontendApps.
+	// +optional
+	StatusField string `json:"statusField,omitempty"`
+
+	// ContextKey is the pipeline context key to write the facts to.
+	// +optional
+	ContextKey string `json:"contextKey,omitempty"`
 }
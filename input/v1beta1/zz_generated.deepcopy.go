@@ -5,14 +5,93 @@
 package v1beta1
 
 import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Alerting) DeepCopyInto(out *Alerting) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Alerting.
+func (in *Alerting) DeepCopy() *Alerting {
+	if in == nil {
+		return nil
+	}
+	out := new(Alerting)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Channels) DeepCopyInto(out *Channels) {
+	*out = *in
+	if in.Telegram != nil {
+		in, out := &in.Telegram, &out.Telegram
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.MSTeams != nil {
+		in, out := &in.MSTeams, &out.MSTeams
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Channels.
+func (in *Channels) DeepCopy() *Channels {
+	if in == nil {
+		return nil
+	}
+	out := new(Channels)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FrontendApps) DeepCopyInto(out *FrontendApps) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FrontendApps.
+func (in *FrontendApps) DeepCopy() *FrontendApps {
+	if in == nil {
+		return nil
+	}
+	out := new(FrontendApps)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Input) DeepCopyInto(out *Input) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Channels.DeepCopyInto(&out.Channels)
+	out.Alerting = in.Alerting
+	out.Teams = in.Teams
+	in.Plugins.DeepCopyInto(&out.Plugins)
+	if in.Stack != nil {
+		in, out := &in.Stack, &out.Stack
+		*out = new(Stack)
+		**out = **in
+	}
+	if in.FrontendApps != nil {
+		in, out := &in.FrontendApps, &out.FrontendApps
+		*out = new(FrontendApps)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Input.
@@ -32,3 +111,53 @@ func (in *Input) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Plugins) DeepCopyInto(out *Plugins) {
+	*out = *in
+	if in.VersionCacheTTL != nil {
+		in, out := &in.VersionCacheTTL, &out.VersionCacheTTL
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Plugins.
+func (in *Plugins) DeepCopy() *Plugins {
+	if in == nil {
+		return nil
+	}
+	out := new(Plugins)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Stack) DeepCopyInto(out *Stack) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Stack.
+func (in *Stack) DeepCopy() *Stack {
+	if in == nil {
+		return nil
+	}
+	out := new(Stack)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Teams) DeepCopyInto(out *Teams) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Teams.
+func (in *Teams) DeepCopy() *Teams {
+	if in == nil {
+		return nil
+	}
+	out := new(Teams)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+	"github.com/grafana/k6-cloud-openapi-client-go/k6"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const k6PageSize = 100
+
+// K6Client is a client with convenience methods
+type K6Client struct {
+	Client    *k6.APIClient
+	Config    *clients.K6APIConfig
+	Projects  []k6.ProjectApiModel
+	LoadTests []k6.LoadTestApiModel
+
+	// Cloud is optional, it looks up the stack of an installation
+	Cloud *CloudClient
+}
+
+// NewK6Client returns a client with convenience methods, the config holds the token and stack ID
+func NewK6Client(client *k6.APIClient, config *clients.K6APIConfig) *K6Client {
+	return &K6Client{
+		Client: client,
+		Config: config,
+	}
+}
+
+func (c *K6Client) context() (context.Context, error) {
+	if c.Config == nil {
+		return nil, errors.New("k6 lookups require k6_access_token and stack_id credentials")
+	}
+	return context.WithValue(context.Background(), k6.ContextAccessToken, c.Config.Token), nil
+}
+
+func (c *K6Client) getProjects() error {
+	// only populate the list if the list is empty
+	if len(c.Projects) != 0 {
+		return nil
+	}
+
+	ctx, err := c.context()
+	if err != nil {
+		return err
+	}
+
+	allProjects := []k6.ProjectApiModel{}
+	for skip := int32(0); ; skip += k6PageSize {
+		resp, _, err := c.Client.ProjectsAPI.ProjectsList(ctx).
+			XStackId(c.Config.StackID).
+			Skip(skip).
+			Top(k6PageSize).
+			Execute()
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list k6 projects")
+		}
+
+		allProjects = append(allProjects, resp.Value...)
+
+		if resp.NextLink == nil || len(resp.Value) == 0 {
+			break
+		}
+	}
+	c.Projects = allProjects
+	return nil
+}
+
+func (c *K6Client) getLoadTests() error {
+	// only populate the list if the list is empty
+	if len(c.LoadTests) != 0 {
+		return nil
+	}
+
+	ctx, err := c.context()
+	if err != nil {
+		return err
+	}
+
+	allLoadTests := []k6.LoadTestApiModel{}
+	for skip := int32(0); ; skip += k6PageSize {
+		resp, _, err := c.Client.LoadTestsAPI.LoadTestsList(ctx).
+			XStackId(c.Config.StackID).
+			Skip(skip).
+			Top(k6PageSize).
+			Execute()
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list k6 load tests")
+		}
+
+		allLoadTests = append(allLoadTests, resp.Value...)
+
+		if resp.NextLink == nil || len(resp.Value) == 0 {
+			break
+		}
+	}
+	c.LoadTests = allLoadTests
+	return nil
+}
+
+// Process processes fields of different kinds
+func (c *K6Client) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	switch gvk.Kind {
+	case "LoadTest", "ProjectAllowedLoadZones", "ProjectLimits":
+		path := "spec.forProvider.projectId"
+		return replaceReferencePath(desired, path, c.GetProjectID)
+
+	case "Schedule":
+		path := "spec.forProvider.loadTestId"
+		return replaceReferencePath(desired, path, c.GetLoadTestID)
+
+	case "Installation":
+		if c.Cloud == nil {
+			return nil
+		}
+		path := "spec.forProvider.stackId"
+		return replaceReferencePath(desired, path, c.Cloud.GetStackID)
+	}
+	return nil
+}
+
+// GetProjectID looks up a project by ID or name and returns its ID
+func (c *K6Client) GetProjectID(name string) (string, error) {
+	project, err := c.FindProject(name)
+	if err != nil {
+		return name, err
+	}
+	return strconv.Itoa(int(project.Id)), nil
+}
+
+// FindProject returns the only project with an ID or name
+func (c *K6Client) FindProject(name string) (*k6.ProjectApiModel, error) {
+	if err := c.getProjects(); err != nil {
+		return nil, err
+	}
+
+	if id, err := strconv.Atoi(name); err == nil {
+		idx := slices.IndexFunc(c.Projects, func(p k6.ProjectApiModel) bool {
+			return int(p.Id) == id
+		})
+		if idx != -1 {
+			return &c.Projects[idx], nil
+		}
+	}
+
+	matches := []int{}
+	for i, p := range c.Projects {
+		if p.Name == name {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 1:
+		return &c.Projects[matches[0]], nil
+	case 0:
+		return nil, errors.Errorf("Could not find k6 project with ID or name: %s", name)
+	default:
+		return nil, errors.Errorf("Found %d k6 projects named %s", len(matches), name)
+	}
+}
+
+// GetLoadTestID looks up a load test by ID, name or "project/load-test-name" and returns its ID
+func (c *K6Client) GetLoadTestID(name string) (string, error) {
+	if err := c.getLoadTests(); err != nil {
+		return name, err
+	}
+
+	if id, err := strconv.Atoi(name); err == nil {
+		if slices.ContainsFunc(c.LoadTests, func(lt k6.LoadTestApiModel) bool {
+			return int(lt.Id) == id
+		}) {
+			return name, nil
+		}
+	}
+
+	ids := c.loadTestIDs(name, nil)
+	// load test names may contain a slash, an exact match takes precedence
+	if project, testName, found := strings.Cut(name, "/"); found && len(ids) == 0 {
+		p, err := c.FindProject(project)
+		if err != nil {
+			return name, err
+		}
+		ids = c.loadTestIDs(testName, p)
+	}
+
+	switch len(ids) {
+	case 1:
+		return ids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find k6 load test with ID or name: %s", name)
+	default:
+		return name, errors.Errorf("Found %d k6 load tests named %s: %s", len(ids), name, strings.Join(ids, ", "))
+	}
+}
+
+// loadTestIDs returns the IDs of the load tests with a name, optionally within a project
+func (c *K6Client) loadTestIDs(name string, project *k6.ProjectApiModel) []string {
+	ids := []string{}
+	for _, lt := range c.LoadTests {
+		if lt.Name == name && (project == nil || lt.ProjectId == project.Id) {
+			ids = append(ids, strconv.Itoa(int(lt.Id)))
+		}
+	}
+	return ids
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+	"github.com/grafana/k6-cloud-openapi-client-go/k6"
+)
+
+func TestK6GetLoadTestID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/cloud/v6/projects", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Stack-Id") != "42" {
+			t.Errorf("unexpected stack ID header: %q", r.Header.Get("X-Stack-Id"))
+		}
+		writeJSON(t, w, map[string]any{
+			"value": []map[string]any{
+				{"id": 1, "name": "checkout", "is_default": true, "grafana_folder_uid": nil, "created": "2026-01-01T00:00:00Z", "updated": "2026-01-01T00:00:00Z"},
+				{"id": 2, "name": "payments", "is_default": false, "grafana_folder_uid": nil, "created": "2026-01-01T00:00:00Z", "updated": "2026-01-01T00:00:00Z"},
+			},
+		})
+	})
+	mux.HandleFunc("/cloud/v6/load_tests", func(w http.ResponseWriter, r *http.Request) {
+		loadTests := []map[string]any{
+			{"id": 10, "project_id": 1, "name": "smoke", "baseline_test_run_id": nil, "created": "2026-01-01T00:00:00Z", "updated": "2026-01-01T00:00:00Z"},
+			{"id": 20, "project_id": 2, "name": "smoke", "baseline_test_run_id": nil, "created": "2026-01-01T00:00:00Z", "updated": "2026-01-01T00:00:00Z"},
+		}
+		// second page
+		if r.URL.Query().Get("$skip") != "0" {
+			writeJSON(t, w, map[string]any{
+				"value": []map[string]any{
+					{"id": 30, "project_id": 2, "name": "soak", "baseline_test_run_id": nil, "created": "2026-01-01T00:00:00Z", "updated": "2026-01-01T00:00:00Z"},
+				},
+			})
+			return
+		}
+		writeJSON(t, w, map[string]any{"value": loadTests, "@nextLink": "next"})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := k6.NewConfiguration()
+	cfg.Servers = k6.ServerConfigurations{{URL: srv.URL}}
+
+	cases := map[string]struct {
+		reason string
+		name   string
+		want   string
+		err    bool
+	}{
+		"LoadTestID": {
+			reason: "An existing load test ID should be returned as-is",
+			name:   "10",
+			want:   "10",
+		},
+		"UniqueName": {
+			reason: "A unique load test name from a later page should resolve to its ID",
+			name:   "soak",
+			want:   "30",
+		},
+		"ProjectAndName": {
+			reason: "A project and load test name should resolve to the load test of that project",
+			name:   "payments/smoke",
+			want:   "20",
+		},
+		"AmbiguousName": {
+			reason: "A load test name used in several projects should return an error",
+			name:   "smoke",
+			want:   "smoke",
+			err:    true,
+		},
+		"UnknownProject": {
+			reason: "An unknown project should return an error",
+			name:   "unknown/smoke",
+			want:   "unknown/smoke",
+			err:    true,
+		},
+	}
+
+	c := NewK6Client(k6.NewAPIClient(cfg), &clients.K6APIConfig{Token: "token", StackID: 42})
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetLoadTestID(tc.name)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetLoadTestID(%q): unexpected error: %v", tc.reason, tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetLoadTestID(%q): -want, +got:\n%s", tc.reason, tc.name, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/grafana/grafana-openapi-client-go/client/library_elements"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/errors"
+)
+
+// libraryElementKindPanel is the kind of library elements that are panels
+const libraryElementKindPanel = int64(1)
+
+func (c *GrafanaClient) getLibraryPanels() error {
+	// only populate the list if the list is empty
+	if len(c.LibraryPanels) != 0 {
+		return nil
+	}
+
+	allPanels := []*models.LibraryElementDTO{}
+	kind := libraryElementKindPanel
+	perPage := int64(100)
+	page := int64(1)
+	for {
+		params := library_elements.NewGetLibraryElementsParams().WithKind(&kind).WithPerPage(&perPage).WithPage(&page)
+		resp, err := c.Client.LibraryElements.GetLibraryElements(params)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list library panels")
+		}
+		result := resp.GetPayload().Result
+		if result == nil {
+			break
+		}
+		allPanels = append(allPanels, result.Elements...)
+
+		if int64(len(result.Elements)) < perPage {
+			break
+		}
+		page++
+	}
+	c.LibraryPanels = allPanels
+	return nil
+}
+
+// GetLibraryPanelUID will return the UID for a library panel UID, name or "<folder path>/<name>"
+func (c *GrafanaClient) GetLibraryPanelUID(name string) (string, error) {
+	if err := c.getLibraryPanels(); err != nil {
+		return name, err
+	}
+
+	if slices.ContainsFunc(c.LibraryPanels, func(p *models.LibraryElementDTO) bool {
+		return p.UID == name
+	}) {
+		return name, nil
+	}
+
+	// library panel names may contain a slash, an exact name match takes precedence
+	uids := c.libraryPanelUIDs(name, nil)
+	if i := strings.LastIndex(name, "/"); i != -1 && len(uids) == 0 {
+		folderUID, err := c.GetFolderUID(name[:i])
+		if err != nil {
+			return name, err
+		}
+		uids = c.libraryPanelUIDs(name[i+1:], &folderUID)
+	}
+
+	switch len(uids) {
+	case 1:
+		return uids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find library panel with UID or name: %s", name)
+	default:
+		return name, errors.Errorf("Found %d library panels named %s, use the folder path instead: %s", len(uids), name, strings.Join(uids, ", "))
+	}
+}
+
+// libraryPanelUIDs returns the UIDs of the library panels with a name, optionally within a folder
+func (c *GrafanaClient) libraryPanelUIDs(name string, folderUID *string) []string {
+	uids := []string{}
+	for _, p := range c.LibraryPanels {
+		if p.Name == name && (folderUID == nil || p.FolderUID == *folderUID) {
+			uids = append(uids, p.UID)
+		}
+	}
+	return uids
+}
+
+// GetDashboardConfigJSON fills in the UIDs of library panels that a dashboard refers to by name
+func (c *GrafanaClient) GetDashboardConfigJSON(config string) (string, error) {
+	// numbers are kept as written, IDs above 2^53 would lose precision as float64
+	var dashboard map[string]any
+	decoder := json.NewDecoder(strings.NewReader(config))
+	decoder.UseNumber()
+	if err := decoder.Decode(&dashboard); err != nil {
+		return config, errors.Wrap(err, "Failed to parse dashboard configJson")
+	}
+
+	changed, err := c.getLibraryPanelRefs(dashboard)
+	if err != nil || !changed {
+		// keep the config as written when there is nothing to fill in
+		return config, err
+	}
+
+	b, err := json.Marshal(dashboard)
+	if err != nil {
+		return config, errors.Wrap(err, "Failed to marshal dashboard configJson")
+	}
+	return string(b), nil
+}
+
+// getLibraryPanelRefs looks up the library panels of panels, including the panels of collapsed rows
+func (c *GrafanaClient) getLibraryPanelRefs(parent map[string]any) (bool, error) {
+	changed := false
+	for _, panel := range nestedObjects(parent, "panels") {
+		nestedChanged, err := c.getLibraryPanelRefs(panel)
+		if err != nil {
+			return false, err
+		}
+		changed = changed || nestedChanged
+
+		ref, ok := panel["libraryPanel"].(map[string]any)
+		if !ok {
+			continue
+		}
+		if uid, _ := ref["uid"].(string); uid != "" {
+			continue
+		}
+		name, ok := ref["name"].(string)
+		if !ok || name == "" {
+			continue
+		}
+		uid, err := c.GetLibraryPanelUID(name)
+		if err != nil {
+			return false, err
+		}
+		ref["uid"] = uid
+		changed = true
+	}
+	return changed, nil
+}
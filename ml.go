@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"slices"
+
+	"github.com/grafana/machine-learning-go-client/mlapi"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// MLClient is a client with convenience methods
+type MLClient struct {
+	Client   *mlapi.Client
+	Grafana  *GrafanaClient
+	Holidays []mlapi.Holiday
+}
+
+// NewMLClient returns a client with convenience methods for Machine Learning and Grafana
+func NewMLClient(client *mlapi.Client, grafana *GrafanaClient) *MLClient {
+	return &MLClient{
+		Client:  client,
+		Grafana: grafana,
+	}
+}
+
+func (c *MLClient) getHolidays() error {
+	// only populate the list if the list is empty
+	if len(c.Holidays) != 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	holidays, err := c.Client.Holidays(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list holidays")
+	}
+
+	c.Holidays = holidays
+	return nil
+}
+
+// Process processes fields of different kinds
+func (c *MLClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	switch gvk.Kind {
+	case "Job":
+		path := "spec.forProvider"
+		if err := replacePath(desired, path, c.GetDataSource); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.holidays"
+		return replaceReferencePath(desired, path, c.GetHolidayIDs)
+
+	case "OutlierDetector":
+		path := "spec.forProvider"
+		return replacePath(desired, path, c.GetDataSource)
+	}
+	return nil
+}
+
+// GetDataSource looks up the data source of a job or outlier detector by UID or name, and sets
+// its UID and, unless already set, its type
+func (c *MLClient) GetDataSource(forProvider map[string]any) (map[string]any, error) {
+	var dsType string
+	err := replaceReferenceKey(forProvider, "datasourceUid", func(name string) (string, error) {
+		ds, err := c.Grafana.FindDataSource(name)
+		if err != nil {
+			return name, err
+		}
+		dsType = ds.Type
+		return ds.UID, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the type is only known for a data source that was found, not for a fallback
+	if _, ok := forProvider["datasourceType"]; !ok && dsType != "" {
+		forProvider["datasourceType"] = dsType
+	}
+	return forProvider, nil
+}
+
+// GetHolidayIDs looks up holidays by ID or name and returns their IDs
+func (c *MLClient) GetHolidayIDs(names []string) ([]string, error) {
+	ids := make([]string, 0, len(names))
+	for _, name := range names {
+		id, err := c.GetHolidayID(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// GetHolidayID looks up a holiday by ID or name and returns its ID
+func (c *MLClient) GetHolidayID(name string) (string, error) {
+	if err := c.getHolidays(); err != nil {
+		return name, err
+	}
+
+	idx := slices.IndexFunc(c.Holidays, func(h mlapi.Holiday) bool {
+		return h.ID == name
+	})
+	if idx != -1 {
+		return name, nil
+	}
+
+	ids := []string{}
+	for _, h := range c.Holidays {
+		if h.Name == name {
+			ids = append(ids, h.ID)
+		}
+	}
+	switch len(ids) {
+	case 1:
+		return ids[0], nil
+	case 0:
+		return name, errors.Errorf("Could not find holiday with ID or name: %s", name)
+	default:
+		return name, errors.Errorf("Found %d holidays named %s", len(ids), name)
+	}
+}
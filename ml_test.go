@@ -0,0 +1,108 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/go-cmp/cmp"
+	goapi "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+	"github.com/grafana/machine-learning-go-client/mlapi"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestMLJob(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/datasources", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.DataSourceList{
+			{UID: "prom-uid", Name: "Prometheus", Type: "prometheus"},
+		})
+	})
+	mux.HandleFunc("/manage/api/v1/holidays", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"status": "success",
+			"data": []mlapi.Holiday{
+				{ID: "h1", Name: "Christmas"},
+				{ID: "h2", Name: "Black Friday"},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+	ml, err := mlapi.New(srv.URL, mlapi.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]struct {
+		reason string
+		job    string
+		want   map[string]any
+		err    bool
+	}{
+		"Names": {
+			reason: "A data source name should set UID and type, holiday names should resolve to IDs",
+			job:    `{"datasourceUid": "Prometheus", "holidays": ["Christmas", "h2"]}`,
+			want: map[string]any{
+				"datasourceUid":  "prom-uid",
+				"datasourceType": "prometheus",
+				"holidays":       []any{"h1", "h2"},
+			},
+		},
+		"ExplicitType": {
+			reason: "An explicit data source type should be kept",
+			job:    `{"datasourceUid": "prom-uid", "datasourceType": "loki"}`,
+			want: map[string]any{
+				"datasourceUid":  "prom-uid",
+				"datasourceType": "loki",
+			},
+		},
+		"UnknownHoliday": {
+			reason: "An unknown holiday should return an error",
+			job:    `{"holidays": ["Easter"]}`,
+			err:    true,
+		},
+	}
+
+	c := NewMLClient(ml, NewGrafanaClient(grafana))
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			job := `{"apiVersion": "ml.grafana.crossplane.io/v1alpha1", "kind": "Job", "spec": {"forProvider": ` + tc.job + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(job)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -2,8 +2,12 @@ package main
 
 import (
 	"slices"
+	"strconv"
+	"strings"
 
 	onCallAPI "github.com/grafana/amixr-api-go-client"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"github.com/grafana/crossplane-provider-grafana/v2/apis/cluster/oncall/v1alpha1"
 
 	"github.com/crossplane/function-sdk-go/errors"
 	"github.com/crossplane/function-sdk-go/resource"
@@ -11,19 +15,41 @@ import (
 
 const (
 	pathTeamID = "spec.forProvider.teamId"
+
+	// teamRefPrefix expands a Grafana team into the OnCall user IDs of its members
+	teamRefPrefix = "team:"
+	// teamRotationRefPrefix expands a Grafana team into one rollingUsers slot per member
+	teamRotationRefPrefix = "team-rotation:"
+
+	// msTeamsChannelIDPrefix starts the ID of a Microsoft Teams channel
+	msTeamsChannelIDPrefix = "19:"
 )
 
 // OnCallClient is a client with convenience methods
 type OnCallClient struct {
-	Client *onCallAPI.Client
-	Users  []*onCallAPI.User
-	Teams  []*onCallAPI.Team
+	Client        *onCallAPI.Client
+	Grafana       *GrafanaClient
+	Channels      v1beta1.Channels
+	Users         []*onCallAPI.User
+	Teams         []*onCallAPI.Team
+	SlackChannels []*onCallAPI.SlackChannel
+	UserGroups    []*onCallAPI.UserGroup
+	Integrations  []*onCallAPI.Integration
+}
+
+// IntegrationSelector selects an OnCall integration, empty fields match any integration
+type IntegrationSelector struct {
+	Team string `json:"team,omitempty"`
+	Type string `json:"type,omitempty"`
+	Name string `json:"name,omitempty"`
 }
 
-// NewOnCallClient returns a client with convenience methods
-func NewOnCallClient(client *onCallAPI.Client) *OnCallClient {
+// NewOnCallClient returns a client with convenience methods, the Grafana client is optional
+func NewOnCallClient(client *onCallAPI.Client, grafana *GrafanaClient, channels v1beta1.Channels) *OnCallClient {
 	return &OnCallClient{
-		Client: client,
+		Client:   client,
+		Grafana:  grafana,
+		Channels: channels,
 	}
 }
 
@@ -60,26 +86,26 @@ func (c *OnCallClient) Process(desired *resource.DesiredComposed) error {
 	switch gvk.Kind {
 	case "Escalation":
 		path := "spec.forProvider.notifyOnCallFromSchedule"
-		if err := replacePath(desired, path, c.GetScheduleID); err != nil {
+		if err := replaceReferencePath(desired, path, c.GetScheduleID); err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.personsToNotify"
-		if err := replacePath(desired, path, c.GetUsers); err != nil {
+		if err := replaceReferencePath(desired, path, c.GetUsers); err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.personsToNotifyNextEachTime"
-		return replacePath(desired, path, c.GetUsers)
+		return replaceReferencePath(desired, path, c.GetUsers)
 
 	case "OnCallShift":
 		path := pathTeamID
-		if err := replacePath(desired, path, c.GetTeamID); err != nil {
+		if err := replaceReferencePath(desired, path, c.GetTeamID); err != nil {
 			return err
 		}
 
 		path = "spec.forProvider.users"
-		if err := replacePath(desired, path, c.GetUsers); err != nil {
+		if err := replaceReferencePath(desired, path, c.GetUsers); err != nil {
 			return err
 		}
 
@@ -88,24 +114,48 @@ func (c *OnCallClient) Process(desired *resource.DesiredComposed) error {
 
 	case "Schedule":
 		path := pathTeamID
-		return replacePath(desired, path, c.GetTeamID)
+		if err := replaceReferencePath(desired, path, c.GetTeamID); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.slack"
+		return replacePath(desired, path, c.GetScheduleSlack)
 
 	case "UserNotificationRule":
 		path := "spec.forProvider.userId"
-		return replacePath(desired, path, c.GetUsers)
+		return replaceReferencePath(desired, path, c.GetUsers)
 
 	case "Integration":
 		path := pathTeamID
-		if err := replacePath(desired, path, c.GetTeamID); err != nil {
+		if err := replaceReferencePath(desired, path, c.GetTeamID); err != nil {
 			return err
 		}
 
-		path = "spec.forProvider.defaultRoute.slack.channelId"
-		return replacePath(desired, path, c.GetSlackChannelID)
+		path = "spec.forProvider.defaultRoute"
+		return replacePath(desired, path, c.GetDefaultRoutes)
+
+	case "Route":
+		path := "spec.forProvider.integrationId"
+		if err := replaceReferencePath(desired, path, c.GetIntegrationID); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.slack"
+		if err := replacePath(desired, path, c.GetRouteSlack); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.telegram"
+		if err := replacePath(desired, path, c.GetRouteTelegram); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.msteams"
+		return replacePath(desired, path, c.GetRouteMSTeams)
 
 	case "EscalationChain":
 		path := pathTeamID
-		return replacePath(desired, path, c.GetTeamID)
+		return replaceReferencePath(desired, path, c.GetTeamID)
 	}
 
 	return nil
@@ -122,7 +172,7 @@ func (c *OnCallClient) getAllTeams() error {
 		}
 		response, _, err := c.Client.Teams.ListTeams(options)
 		if err != nil {
-			return errors.Wrapf(err, "Failed to list oncall users")
+			return errors.Wrapf(err, "Failed to list oncall teams")
 		}
 
 		allTeams = append(allTeams, response.Teams...)
@@ -136,32 +186,99 @@ func (c *OnCallClient) getAllTeams() error {
 	return nil
 }
 
-// GetUsers looks up users and returns the IDs
+// GetUsers looks up users and returns the IDs, team references are expanded to their members
 func (c *OnCallClient) GetUsers(userIDs []string) ([]string, error) {
 	newVal := []string{}
 	for _, id := range userIDs {
+		if strings.HasPrefix(id, teamRotationRefPrefix) {
+			return nil, errors.Errorf("%s can only be used as the only entry of a rollingUsers slot", id)
+		}
+
+		if team, ok := strings.CutPrefix(id, teamRefPrefix); ok {
+			teamUserIDs, err := c.GetTeamUsers(team)
+			if err != nil {
+				return nil, err
+			}
+			newVal = append(newVal, teamUserIDs...)
+			continue
+		}
+
 		userID, err := c.GetUserID(id)
 		if err != nil {
 			return nil, err
 		}
 		newVal = append(newVal, userID)
 	}
-	return newVal, nil
+	return dedupe(newVal), nil
 }
 
-// GetRollingUsers looks up rolling users (a nested array)
+// GetRollingUsers looks up rolling users (a nested array), a slot with only a team rotation
+// reference is split into one slot per team member
 func (c *OnCallClient) GetRollingUsers(val [][]string) ([][]string, error) {
 	newVal := [][]string{}
 	for _, userIDs := range val {
-		usernames, err := c.GetUsers(userIDs)
-		if err != nil {
-			return nil, err
+		if len(userIDs) == 1 && strings.HasPrefix(parseReference(userIDs[0]).Name, teamRotationRefPrefix) {
+			teamUserIDs, err := lookupReferences(userIDs[0], func(ref string) ([]string, error) {
+				return c.GetTeamUsers(strings.TrimPrefix(ref, teamRotationRefPrefix))
+			})
+			if err != nil {
+				return nil, err
+			}
+			for _, userID := range teamUserIDs {
+				newVal = append(newVal, []string{userID})
+			}
+			continue
 		}
-		newVal = append(newVal, usernames)
+
+		usernames := []string{}
+		for _, id := range userIDs {
+			ids, err := lookupReferences(id, func(id string) ([]string, error) {
+				return c.GetUsers([]string{id})
+			})
+			if err != nil {
+				return nil, err
+			}
+			usernames = append(usernames, ids...)
+		}
+		// a slot of optional users that cannot be resolved is removed
+		if len(usernames) == 0 && len(userIDs) != 0 {
+			continue
+		}
+		newVal = append(newVal, dedupe(usernames))
 	}
 	return newVal, nil
 }
 
+// GetTeamUsers looks up the members of a Grafana team and returns their OnCall user IDs
+func (c *OnCallClient) GetTeamUsers(team string) ([]string, error) {
+	if c.Grafana == nil {
+		return nil, errors.Errorf("Could not expand team %s, no Grafana client configured", team)
+	}
+
+	members, err := c.Grafana.GetTeamMembers(team)
+	if err != nil {
+		return nil, err
+	}
+
+	userIDs := make([]string, 0, len(members))
+	for _, member := range members {
+		name := member.Email
+		if name == "" {
+			name = member.Login
+		}
+		userID, err := c.GetUserID(name)
+		if err != nil && name != member.Login {
+			// OnCall usernames match the Grafana login
+			userID, err = c.GetUserID(member.Login)
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "Could not find OnCall user for member %s of team %s", member.Login, team)
+		}
+		userIDs = append(userIDs, userID)
+	}
+	return dedupe(userIDs), nil
+}
+
 // GetUserID looks up a user
 func (c *OnCallClient) GetUserID(id string) (string, error) {
 	// populate the list if the list is empty
@@ -189,10 +306,14 @@ func (c *OnCallClient) GetUserID(id string) (string, error) {
 		return c.Users[usernameEmailIDx].ID, nil
 	}
 
-	return "", errors.Errorf("Could not find user with name %s", id)
+	names := make([]string, 0, 2*len(c.Users))
+	for _, u := range c.Users {
+		names = append(names, u.Username, u.Email)
+	}
+	return "", errors.Errorf("Could not find user with name %s%s", id, didYouMean(id, names))
 }
 
-// GetTeamID looks up a team
+// GetTeamID looks up a team by OnCall team ID, name or email, or by Grafana team ID or name
 func (c *OnCallClient) GetTeamID(id string) (string, error) {
 	if len(c.Teams) == 0 {
 		err := c.getAllTeams()
@@ -206,10 +327,10 @@ func (c *OnCallClient) GetTeamID(id string) (string, error) {
 		return c.ID == id
 	})
 	if idx != -1 {
-		return c.Users[idx].ID, nil
+		return c.Teams[idx].ID, nil
 	}
 
-	// if the provided ID does not exist, try to look up by username or email
+	// if the provided ID does not exist, try to look up by name or email
 	teamEmailIDx := slices.IndexFunc(c.Teams, func(c *onCallAPI.Team) bool {
 		return c.Name == id || c.Email == id
 	})
@@ -218,9 +339,136 @@ func (c *OnCallClient) GetTeamID(id string) (string, error) {
 		return c.Teams[teamEmailIDx].ID, nil
 	}
 
+	// OnCall teams are synced from Grafana teams, try to look up the Grafana team by ID or name
+	if c.Grafana != nil {
+		grafanaTeam, err := c.Grafana.FindTeam(id)
+		if err != nil {
+			return "", errors.Wrapf(err, "Could not find team with ID %s", id)
+		}
+
+		grafanaTeamIDx := slices.IndexFunc(c.Teams, func(c *onCallAPI.Team) bool {
+			return c.Name == grafanaTeam.Name || (grafanaTeam.Email != "" && c.Email == grafanaTeam.Email)
+		})
+		if grafanaTeamIDx != -1 {
+			return c.Teams[grafanaTeamIDx].ID, nil
+		}
+	}
+
 	return "", errors.Errorf("Could not find team with ID %s", id)
 }
 
+func (c *OnCallClient) getAllIntegrations() error {
+	allIntegrations := []*onCallAPI.Integration{}
+	page := 1
+	for {
+		options := &onCallAPI.ListIntegrationOptions{
+			ListOptions: onCallAPI.ListOptions{
+				Page: page,
+			},
+		}
+		response, _, err := c.Client.Integrations.ListIntegrations(options)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list oncall integrations")
+		}
+
+		allIntegrations = append(allIntegrations, response.Integrations...)
+
+		if response.Next == nil {
+			break
+		}
+		page++
+	}
+	c.Integrations = allIntegrations
+	return nil
+}
+
+// ParseIntegrationRef returns the selector for an integration reference, either an integration
+// name or an integration name prefixed with its team as "team/integration-name"
+func (c *OnCallClient) ParseIntegrationRef(ref string) (IntegrationSelector, error) {
+	if len(c.Integrations) == 0 {
+		if err := c.getAllIntegrations(); err != nil {
+			return IntegrationSelector{}, err
+		}
+	}
+
+	// integration names may contain a slash, an exact match takes precedence
+	if slices.ContainsFunc(c.Integrations, func(i *onCallAPI.Integration) bool {
+		return i.Name == ref
+	}) {
+		return IntegrationSelector{Name: ref}, nil
+	}
+
+	if team, name, found := strings.Cut(ref, "/"); found {
+		return IntegrationSelector{Team: team, Name: name}, nil
+	}
+	return IntegrationSelector{Name: ref}, nil
+}
+
+// FindIntegration looks up the only integration matching a selector
+func (c *OnCallClient) FindIntegration(selector IntegrationSelector) (*onCallAPI.Integration, error) {
+	if len(c.Integrations) == 0 {
+		if err := c.getAllIntegrations(); err != nil {
+			return nil, err
+		}
+	}
+
+	teamID := ""
+	if selector.Team != "" {
+		id, err := c.GetTeamID(selector.Team)
+		if err != nil {
+			return nil, err
+		}
+		teamID = id
+	}
+
+	matches := []*onCallAPI.Integration{}
+	for _, i := range c.Integrations {
+		if (teamID == "" || i.TeamId == teamID) &&
+			(selector.Type == "" || i.Type == selector.Type) &&
+			(selector.Name == "" || i.Name == selector.Name) {
+			matches = append(matches, i)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return nil, errors.Errorf("Could not find oncall integration matching %+v", selector)
+	default:
+		ids := make([]string, 0, len(matches))
+		for _, i := range matches {
+			ids = append(ids, i.ID)
+		}
+		return nil, errors.Errorf("Found %d oncall integrations matching %+v: %s", len(matches), selector, strings.Join(ids, ", "))
+	}
+}
+
+// GetIntegrationID looks up an integration by ID, name or "team/integration-name"
+func (c *OnCallClient) GetIntegrationID(ref string) (string, error) {
+	if len(c.Integrations) == 0 {
+		if err := c.getAllIntegrations(); err != nil {
+			return "", err
+		}
+	}
+
+	if slices.ContainsFunc(c.Integrations, func(i *onCallAPI.Integration) bool {
+		return i.ID == ref
+	}) {
+		return ref, nil
+	}
+
+	selector, err := c.ParseIntegrationRef(ref)
+	if err != nil {
+		return "", err
+	}
+	integration, err := c.FindIntegration(selector)
+	if err != nil {
+		return "", err
+	}
+	return integration.ID, nil
+}
+
 // GetScheduleID looks up a schedule
 func (c *OnCallClient) GetScheduleID(id string) (string, error) {
 	options := &onCallAPI.ListScheduleOptions{
@@ -232,30 +480,217 @@ func (c *OnCallClient) GetScheduleID(id string) (string, error) {
 	}
 
 	if len(response.Schedules) == 0 {
-		return "", errors.Wrapf(err, "No schedules found for name %s", id)
+		return id, errors.Errorf("No schedules found for name %s", id)
 	}
 
 	return response.Schedules[0].ID, nil
 }
 
-// GetSlackChannelID looks up a slack channel ID
+func (c *OnCallClient) getAllSlackChannels() error {
+	allSlackChannels := []*onCallAPI.SlackChannel{}
+	page := 1
+	for {
+		options := &onCallAPI.ListSlackChannelOptions{
+			ListOptions: onCallAPI.ListOptions{
+				Page: page,
+			},
+		}
+		response, _, err := c.Client.SlackChannels.ListSlackChannels(options)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list oncall slack channels")
+		}
+
+		allSlackChannels = append(allSlackChannels, response.SlackChannels...)
+
+		if response.Next == nil {
+			break
+		}
+		page++
+	}
+	c.SlackChannels = allSlackChannels
+	return nil
+}
+
+func (c *OnCallClient) getAllUserGroups() error {
+	allUserGroups := []*onCallAPI.UserGroup{}
+	page := 1
+	for {
+		options := &onCallAPI.ListUserGroupOptions{
+			ListOptions: onCallAPI.ListOptions{
+				Page: page,
+			},
+		}
+		response, _, err := c.Client.UserGroups.ListUserGroups(options)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list oncall user groups")
+		}
+
+		allUserGroups = append(allUserGroups, response.UserGroups...)
+
+		if response.Next == nil {
+			break
+		}
+		page++
+	}
+	c.UserGroups = allUserGroups
+	return nil
+}
+
+// GetSlackChannelID looks up a slack channel ID by name, existing channel IDs are returned as-is
 func (c *OnCallClient) GetSlackChannelID(name string) (string, error) {
-	options := &onCallAPI.ListSlackChannelOptions{
-		ChannelName: name,
+	if len(c.SlackChannels) == 0 {
+		err := c.getAllSlackChannels()
+		if err != nil {
+			return "", err
+		}
 	}
 
-	slackChannelsResponse, _, err := c.Client.SlackChannels.ListSlackChannels(options)
-	if err != nil {
-		return "", err
+	channelName := strings.TrimPrefix(name, "#")
+	matches := []string{}
+	for _, slackChannel := range c.SlackChannels {
+		if slackChannel.Name == channelName {
+			matches = append(matches, slackChannel.SlackId)
+		}
+	}
+
+	if len(matches) == 1 {
+		return matches[0], nil
+	}
+	if len(matches) > 1 {
+		return "", errors.Errorf("Found %d slack channels with name %s: %s", len(matches), name, strings.Join(matches, ", "))
 	}
 
-	if len(slackChannelsResponse.SlackChannels) == 0 {
+	// if the provided name does not exist, check if it is already a slack channel ID
+	idx := slices.IndexFunc(c.SlackChannels, func(c *onCallAPI.SlackChannel) bool {
+		return c.SlackId == name
+	})
+	if idx != -1 {
+		return c.SlackChannels[idx].SlackId, nil
+	}
+
+	return "", errors.Errorf("Could not find slack channel with name %s", name)
+}
+
+// GetSlackUserGroupID looks up a slack user group ID by handle or name, existing user group IDs are returned as-is
+func (c *OnCallClient) GetSlackUserGroupID(name string) (string, error) {
+	if len(c.UserGroups) == 0 {
+		err := c.getAllUserGroups()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	handle := strings.TrimPrefix(name, "@")
+	matches := []string{}
+	for _, userGroup := range c.UserGroups {
+		if userGroup.SlackUserGroup == nil {
+			continue
+		}
+		if userGroup.SlackUserGroup.ID == name {
+			return name, nil
+		}
+		if userGroup.SlackUserGroup.Handle == handle || userGroup.SlackUserGroup.Name == name {
+			matches = append(matches, userGroup.SlackUserGroup.ID)
+		}
+	}
+
+	switch len(matches) {
+	case 1:
+		return matches[0], nil
+	case 0:
+		return "", errors.Errorf("Could not find slack user group with handle or name %s", name)
+	default:
+		return "", errors.Errorf("Found %d slack user groups with handle or name %s: %s", len(matches), name, strings.Join(matches, ", "))
+	}
+}
+
+// GetTelegramChannelID looks up a Telegram chat ID from the channels configured in the Input, a
+// numeric chat ID is returned as-is
+func (c *OnCallClient) GetTelegramChannelID(name string) (string, error) {
+	if id, ok := c.Channels.Telegram[name]; ok {
+		return id, nil
+	}
+	if _, err := strconv.ParseInt(name, 10, 64); err == nil {
 		return name, nil
-	} else if len(slackChannelsResponse.SlackChannels) != 1 {
+	}
+	return name, errors.Errorf("Could not find Telegram channel %s in the channels of the Function input", name)
+}
+
+// GetMSTeamsChannelID looks up a Microsoft Teams channel ID from the channels configured in the
+// Input, a channel ID like 19:...@thread.tacv2 is returned as-is
+func (c *OnCallClient) GetMSTeamsChannelID(name string) (string, error) {
+	if id, ok := c.Channels.MSTeams[name]; ok {
+		return id, nil
+	}
+	if strings.HasPrefix(name, msTeamsChannelIDPrefix) && strings.Contains(name, "@thread.") {
 		return name, nil
 	}
+	return name, errors.Errorf("Could not find Microsoft Teams channel %s in the channels of the Function input", name)
+}
+
+// GetDefaultRoutes looks up the chat channels of an integration's default route
+func (c *OnCallClient) GetDefaultRoutes(routes []v1alpha1.DefaultRouteParameters) ([]v1alpha1.DefaultRouteParameters, error) {
+	newRoutes := make([]v1alpha1.DefaultRouteParameters, 0, len(routes))
+	for _, r := range routes {
+		for i := range r.Slack {
+			if err := replaceValue(&r.Slack[i].ChannelID, c.GetSlackChannelID); err != nil {
+				return nil, err
+			}
+		}
+		for i := range r.Telegram {
+			if err := replaceValue(&r.Telegram[i].ID, c.GetTelegramChannelID); err != nil {
+				return nil, err
+			}
+		}
+		for i := range r.Msteams {
+			if err := replaceValue(&r.Msteams[i].ID, c.GetMSTeamsChannelID); err != nil {
+				return nil, err
+			}
+		}
+		newRoutes = append(newRoutes, r)
+	}
+	return newRoutes, nil
+}
 
-	slackChannel := slackChannelsResponse.SlackChannels[0]
+// GetRouteSlack looks up the slack channels of a route
+func (c *OnCallClient) GetRouteSlack(slack []v1alpha1.RouteSlackParameters) ([]v1alpha1.RouteSlackParameters, error) {
+	for i := range slack {
+		if err := replaceValue(&slack[i].ChannelID, c.GetSlackChannelID); err != nil {
+			return nil, err
+		}
+	}
+	return slack, nil
+}
 
-	return slackChannel.SlackId, nil
+// GetRouteTelegram looks up the Telegram channels of a route
+func (c *OnCallClient) GetRouteTelegram(telegram []v1alpha1.RouteTelegramParameters) ([]v1alpha1.RouteTelegramParameters, error) {
+	for i := range telegram {
+		if err := replaceValue(&telegram[i].ID, c.GetTelegramChannelID); err != nil {
+			return nil, err
+		}
+	}
+	return telegram, nil
+}
+
+// GetRouteMSTeams looks up the Microsoft Teams channels of a route
+func (c *OnCallClient) GetRouteMSTeams(msteams []v1alpha1.RouteMsteamsParameters) ([]v1alpha1.RouteMsteamsParameters, error) {
+	for i := range msteams {
+		if err := replaceValue(&msteams[i].ID, c.GetMSTeamsChannelID); err != nil {
+			return nil, err
+		}
+	}
+	return msteams, nil
+}
+
+// GetScheduleSlack looks up the slack channel and user group of a schedule
+func (c *OnCallClient) GetScheduleSlack(slack []v1alpha1.ScheduleSlackParameters) ([]v1alpha1.ScheduleSlackParameters, error) {
+	for i := range slack {
+		if err := replaceValue(&slack[i].ChannelID, c.GetSlackChannelID); err != nil {
+			return nil, err
+		}
+		if err := replaceValue(&slack[i].UserGroupID, c.GetSlackUserGroupID); err != nil {
+			return nil, err
+		}
+	}
+	return slack, nil
 }
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/go-openapi/strfmt"
+	"github.com/google/go-cmp/cmp"
+	onCallAPI "github.com/grafana/amixr-api-go-client"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	goapi "github.com/grafana/grafana-openapi-client-go/client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+)
+
+// newTestOnCallClient returns an OnCallClient backed by local OnCall and Grafana API stand-ins
+func newTestOnCallClient(t *testing.T, handler http.Handler) *OnCallClient {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	oncall, err := onCallAPI.New(srv.URL, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grafana := goapi.NewHTTPClientWithConfig(strfmt.Default, &goapi.TransportConfig{
+		Host:     u.Host,
+		BasePath: "/api",
+		Schemes:  []string{"http"},
+	})
+
+	return NewOnCallClient(oncall, NewGrafanaClient(grafana), v1beta1.Channels{})
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, v any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestOnCallGetTeamID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/teams", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 2,
+			"results": []onCallAPI.Team{
+				{ID: "TA1", Name: "platform", Email: "platform@example.com"},
+				{ID: "TB2", Name: "payments"},
+				{ID: "TC3", Name: "sre", Email: "sre@example.com"},
+			},
+		})
+	})
+	mux.HandleFunc("/api/teams/42", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.TeamDTO{ID: 42, Name: "payments"})
+	})
+	mux.HandleFunc("/api/teams/search", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("name") == "Site Reliability" {
+			writeJSON(t, w, models.SearchTeamQueryResult{Teams: []*models.TeamDTO{
+				{ID: 7, Name: "Site Reliability", Email: "sre@example.com"},
+			}})
+			return
+		}
+		writeJSON(t, w, models.SearchTeamQueryResult{})
+	})
+	mux.HandleFunc("/api/teams/", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	cases := map[string]struct {
+		reason string
+		id     string
+		want   string
+		err    bool
+	}{
+		"OnCallTeamID": {
+			reason: "An existing OnCall team ID should be returned as-is",
+			id:     "TB2",
+			want:   "TB2",
+		},
+		"OnCallTeamName": {
+			reason: "An OnCall team name should resolve to its ID",
+			id:     "platform",
+			want:   "TA1",
+		},
+		"OnCallTeamEmail": {
+			reason: "An OnCall team email should resolve to its ID",
+			id:     "platform@example.com",
+			want:   "TA1",
+		},
+		"GrafanaTeamID": {
+			reason: "A Grafana team ID should resolve to the OnCall team with the same name",
+			id:     "42",
+			want:   "TB2",
+		},
+		"GrafanaTeamName": {
+			reason: "A Grafana team name should resolve to the OnCall team with the same email",
+			id:     "Site Reliability",
+			want:   "TC3",
+		},
+		"UnknownTeam": {
+			reason: "An unknown team should return an error",
+			id:     "unknown",
+			err:    true,
+		},
+	}
+
+	c := newTestOnCallClient(t, mux)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetTeamID(tc.id)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetTeamID(%q): unexpected error: %v", tc.reason, tc.id, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetTeamID(%q): -want, +got:\n%s", tc.reason, tc.id, diff)
+			}
+		})
+	}
+}
+
+func TestOnCallGetRollingUsers(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/users/", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 3,
+			"results": []onCallAPI.User{
+				{ID: "UA1", Username: "alice", Email: "alice@example.com"},
+				{ID: "UB2", Username: "bob", Email: "bob@example.com"},
+				{ID: "UC3", Username: "carol", Email: "carol@example.com"},
+			},
+		})
+	})
+	mux.HandleFunc("/api/teams/search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.SearchTeamQueryResult{
+			Teams: []*models.TeamDTO{{ID: 7, Name: "sre-primary"}},
+		})
+	})
+	mux.HandleFunc("/api/teams/7/members", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, []models.TeamMemberDTO{
+			{Login: "bob", Email: "bob@example.com"},
+			{Login: "alice"},
+		})
+	})
+
+	cases := map[string]struct {
+		reason string
+		val    [][]string
+		want   [][]string
+	}{
+		"TeamInSlot": {
+			reason: "A team reference should expand to all members in the same slot, without duplicates",
+			val:    [][]string{{"team:sre-primary", "alice@example.com"}, {"carol"}},
+			want:   [][]string{{"UA1", "UB2"}, {"UC3"}},
+		},
+		"TeamRotation": {
+			reason: "A team rotation reference should expand to one slot per member",
+			val:    [][]string{{"team-rotation:sre-primary"}, {"carol"}},
+			want:   [][]string{{"UA1"}, {"UB2"}, {"UC3"}},
+		},
+	}
+
+	c := newTestOnCallClient(t, mux)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetRollingUsers(tc.val)
+			if err != nil {
+				t.Fatalf("%s\nc.GetRollingUsers(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetRollingUsers(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestOnCallGetSlackChannelID(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/slack_channels", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 3,
+			"results": []onCallAPI.SlackChannel{
+				{Name: "alerts", SlackId: "C01"},
+				{Name: "oncall", SlackId: "C02"},
+				{Name: "oncall", SlackId: "C03"},
+			},
+		})
+	})
+
+	cases := map[string]struct {
+		reason string
+		name   string
+		want   string
+		err    bool
+	}{
+		"ChannelName": {
+			reason: "A channel name should resolve to its slack ID",
+			name:   "#alerts",
+			want:   "C01",
+		},
+		"ChannelID": {
+			reason: "An existing slack ID should be returned as-is",
+			name:   "C02",
+			want:   "C02",
+		},
+		"AmbiguousChannelName": {
+			reason: "A channel name with several matches should return an error",
+			name:   "oncall",
+			err:    true,
+		},
+		"UnknownChannel": {
+			reason: "An unknown channel should return an error",
+			name:   "unknown",
+			err:    true,
+		},
+	}
+
+	c := newTestOnCallClient(t, mux)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetSlackChannelID(tc.name)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetSlackChannelID(%q): unexpected error: %v", tc.reason, tc.name, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetSlackChannelID(%q): -want, +got:\n%s", tc.reason, tc.name, diff)
+			}
+		})
+	}
+}
+
+func TestOnCallFindIntegration(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/teams", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 2,
+			"results": []onCallAPI.Team{
+				{ID: "TA1", Name: "platform"},
+				{ID: "TB2", Name: "payments"},
+			},
+		})
+	})
+	mux.HandleFunc("/api/v1/integrations/", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 4,
+			"results": []onCallAPI.Integration{
+				{ID: "I1", Name: "alerts", TeamId: "TA1", Type: "grafana_alerting", Link: "https://oncall/i1"},
+				{ID: "I2", Name: "alerts", TeamId: "TB2", Type: "grafana_alerting", Link: "https://oncall/i2"},
+				{ID: "I3", Name: "webhook", TeamId: "TB2", Type: "webhook", Link: "https://oncall/i3"},
+				{ID: "I4", Name: "ops/legacy", Type: "webhook", Link: "https://oncall/i4"},
+			},
+		})
+	})
+
+	cases := map[string]struct {
+		reason string
+		ref    string
+		want   string
+		err    bool
+	}{
+		"IntegrationID": {
+			reason: "An existing integration ID should be returned as-is",
+			ref:    "I3",
+			want:   "I3",
+		},
+		"UniqueName": {
+			reason: "A unique integration name should resolve to its ID",
+			ref:    "webhook",
+			want:   "I3",
+		},
+		"TeamAndName": {
+			reason: "A team and integration name should resolve to the integration of that team",
+			ref:    "payments/alerts",
+			want:   "I2",
+		},
+		"NameWithSlash": {
+			reason: "An exact integration name should take precedence over a team prefix",
+			ref:    "ops/legacy",
+			want:   "I4",
+		},
+		"AmbiguousName": {
+			reason: "An integration name used by several teams should return an error",
+			ref:    "alerts",
+			err:    true,
+		},
+		"UnknownTeam": {
+			reason: "An unknown team should return an error",
+			ref:    "unknown/alerts",
+			err:    true,
+		},
+	}
+
+	c := newTestOnCallClient(t, mux)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetIntegrationID(tc.ref)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetIntegrationID(%q): unexpected error: %v", tc.reason, tc.ref, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetIntegrationID(%q): -want, +got:\n%s", tc.reason, tc.ref, diff)
+			}
+		})
+	}
+
+	got, err := c.FindIntegration(IntegrationSelector{Team: "platform", Type: "grafana_alerting"})
+	if err != nil {
+		t.Fatalf("c.FindIntegration(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff("https://oncall/i1", got.Link); diff != "" {
+		t.Errorf("c.FindIntegration(...): -want, +got:\n%s", diff)
+	}
+}
+
+func TestOnCallGetChatChannelIDs(t *testing.T) {
+	c := NewOnCallClient(nil, nil, v1beta1.Channels{
+		Telegram: map[string]string{"sre-alerts": "-1001234567890"},
+		MSTeams:  map[string]string{"sre-alerts": "19:abcdef@thread.tacv2"},
+	})
+
+	cases := map[string]struct {
+		reason string
+		lookup func(string) (string, error)
+		name   string
+		want   string
+		err    bool
+	}{
+		"TelegramName": {
+			reason: "A Telegram channel name from the Input should resolve to its chat ID",
+			lookup: c.GetTelegramChannelID,
+			name:   "sre-alerts",
+			want:   "-1001234567890",
+		},
+		"TelegramID": {
+			reason: "A numeric Telegram chat ID should be returned as-is",
+			lookup: c.GetTelegramChannelID,
+			name:   "-1009876543210",
+			want:   "-1009876543210",
+		},
+		"TelegramUnknown": {
+			reason: "A Telegram channel name missing from the Input should return an error",
+			lookup: c.GetTelegramChannelID,
+			name:   "payments-alerts",
+			err:    true,
+		},
+		"MSTeamsName": {
+			reason: "An MS Teams channel name from the Input should resolve to its channel ID",
+			lookup: c.GetMSTeamsChannelID,
+			name:   "sre-alerts",
+			want:   "19:abcdef@thread.tacv2",
+		},
+		"MSTeamsID": {
+			reason: "An MS Teams channel ID should be returned as-is",
+			lookup: c.GetMSTeamsChannelID,
+			name:   "19:123456@thread.skype",
+			want:   "19:123456@thread.skype",
+		},
+		"MSTeamsUnknown": {
+			reason: "An MS Teams channel name missing from the Input should return an error",
+			lookup: c.GetMSTeamsChannelID,
+			name:   "payments-alerts",
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := tc.lookup(tc.name)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nlookup(%q): unexpected error: %v", tc.reason, tc.name, err)
+			}
+			if tc.err {
+				return
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nlookup(%q): -want, +got:\n%s", tc.reason, tc.name, diff)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@
 package clients
 
 import (
+	"math"
 	"strconv"
 
 	onCallAPI "github.com/grafana/amixr-api-go-client"
@@ -31,12 +32,21 @@ type Client struct {
 	SLOClient             *slo.APIClient
 	AssertsAPIClient      *assertsapi.APIClient
 	K6APIClient           *k6.APIClient
+	K6APIConfig           *K6APIConfig
+	FleetManagementClient *FleetManagementClient
+	FrontendO11yAPIClient *FrontendO11yClient
+	// GrafanaStackID scopes the Asserts requests
+	GrafanaStackID int64
 	// in internal package
 	// CloudProviderAPI      *cloudproviderapi.Client
 	// ConnectionsAPIClient  *connectionsapi.Client
-	// FleetManagementClient *fleetmanagementapi.Client
-	// FrontendO11yAPIClient *frontendo11yapi.Client
-	// K6APIConfig *k6providerapi.K6APIConfig
+}
+
+// K6APIConfig holds the k6 Cloud API token and stack ID, required on every k6 API request
+// (copy of the internal k6providerapi.K6APIConfig)
+type K6APIConfig struct {
+	Token   string
+	StackID int32
 }
 
 // NewClientsFromProviderConfig creates a Client struct from a Crossplane ProviderConfig/credentials
@@ -65,6 +75,36 @@ func NewClientsFromProviderConfig(pc *v1beta1.ProviderConfig, credentials map[st
 		SLOClient:             clients.SLOClient,
 		AssertsAPIClient:      clients.AssertsAPIClient,
 		K6APIClient:           clients.K6APIClient,
+		GrafanaStackID:        clients.GrafanaStackID,
+	}
+	if clients.K6APIConfig != nil {
+		client.K6APIConfig = &K6APIConfig{
+			Token:   clients.K6APIConfig.Token,
+			StackID: clients.K6APIConfig.StackID,
+		}
+	}
+	// the Fleet Management client of the TF provider is in an internal package
+	if !cfg.FleetManagementAuth.IsNull() && !cfg.FleetManagementURL.IsNull() {
+		client.FleetManagementClient = NewFleetManagementClient(
+			cfg.FleetManagementAuth.ValueString(),
+			cfg.FleetManagementURL.ValueString(),
+		)
+	}
+	// the Frontend Observability client of the TF provider is in an internal package, it falls back
+	// to the Cloud access policy token like the TF provider
+	if !cfg.FrontendO11yAPIAccessToken.IsNull() || !cfg.CloudAccessPolicyToken.IsNull() {
+		token := cfg.FrontendO11yAPIAccessToken.ValueString()
+		if cfg.FrontendO11yAPIAccessToken.IsNull() {
+			token = cfg.CloudAccessPolicyToken.ValueString()
+		}
+		client.FrontendO11yAPIClient, err = NewFrontendO11yClient(
+			cfg.FrontendO11YAPIURL.ValueString(),
+			cfg.CloudAPIURL.ValueString(),
+			token,
+		)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return &client, nil
@@ -94,6 +134,7 @@ func createCrossplaneConfiguration(pc *v1beta1.ProviderConfig, creds map[string]
 		"fleet_management_url",
 
 		"frontend_o11y_api_access_token",
+		"frontend_o11y_api_url",
 
 		"oncall_access_token",
 		"oncall_url",
@@ -107,6 +148,7 @@ func createCrossplaneConfiguration(pc *v1beta1.ProviderConfig, creds map[string]
 		// required for k6 resources
 		"stack_id",
 		"k6_access_token",
+		"k6_url",
 	} {
 		if v, ok := creds[k]; ok {
 			if k == "org_id" || k == "stack_id" {
@@ -151,12 +193,20 @@ func createCrossplaneConfiguration(pc *v1beta1.ProviderConfig, creds map[string]
 	return config, nil
 }
 
+// convertToInt converts a credential value to int, JSON credentials decode numbers as float64
 func convertToInt(a any) (int, error) {
 	switch t := a.(type) {
 	case string:
-		return strconv.Atoi(a.(string))
+		return strconv.Atoi(t)
 	case int:
-		return a.(int), nil
+		return t, nil
+	case int64:
+		return int(t), nil
+	case float64:
+		if t != math.Trunc(t) {
+			return 0, errors.Errorf("could not convert %v to int", t)
+		}
+		return int(t), nil
 	default:
 		return 0, errors.Errorf("could not convert %T to int", t)
 	}
@@ -187,6 +237,7 @@ func createTFConfiguration(d map[string]any) (*grafanaProvider.ProviderConfig, e
 		FleetManagementAuth:        stringValueOrNull(d, "fleet_management_auth"),
 		FleetManagementURL:         stringValueOrNull(d, "fleet_management_url"),
 		FrontendO11yAPIAccessToken: stringValueOrNull(d, "frontend_o11y_api_access_token"),
+		FrontendO11YAPIURL:         stringValueOrNull(d, "frontend_o11y_api_url"),
 		K6URL:                      stringValueOrNull(d, "k6_url"),
 		K6AccessToken:              stringValueOrNull(d, "k6_access_token"),
 		StoreDashboardSha256:       boolValueOrNull(d, "store_dashboard_sha256"),
@@ -0,0 +1,43 @@
+package clients
+
+import (
+	"encoding/base64"
+	"net/http"
+
+	"github.com/grafana/fleet-management-api/api/gen/proto/go/collector/v1/collectorv1connect"
+	"github.com/grafana/fleet-management-api/api/gen/proto/go/pipeline/v1/pipelinev1connect"
+)
+
+// FleetManagementClient holds the Fleet Management service clients
+// (copy of the internal fleetmanagementapi.Client)
+type FleetManagementClient struct {
+	CollectorServiceClient collectorv1connect.CollectorServiceClient
+	PipelineServiceClient  pipelinev1connect.PipelineServiceClient
+}
+
+// NewFleetManagementClient creates the Fleet Management service clients, auth is "<username>:<token>"
+func NewFleetManagementClient(auth, url string) *FleetManagementClient {
+	httpClient := &http.Client{
+		Transport: &basicAuthTransport{
+			auth: base64.StdEncoding.EncodeToString([]byte(auth)),
+			base: http.DefaultTransport,
+		},
+	}
+
+	return &FleetManagementClient{
+		CollectorServiceClient: collectorv1connect.NewCollectorServiceClient(httpClient, url),
+		PipelineServiceClient:  pipelinev1connect.NewPipelineServiceClient(httpClient, url),
+	}
+}
+
+// basicAuthTransport sets the basic auth header on every request
+type basicAuthTransport struct {
+	auth string
+	base http.RoundTripper
+}
+
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Basic "+t.auth)
+	return t.base.RoundTrip(clone)
+}
@@ -0,0 +1,119 @@
+package clients
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/crossplane/function-sdk-go/errors"
+)
+
+// FrontendO11yClient lists Frontend Observability apps
+// (minimal copy of the internal frontendo11yapi.Client)
+type FrontendO11yClient struct {
+	APIURL       string
+	CloudAPIHost string
+	Token        string
+	HTTPClient   *http.Client
+}
+
+// FrontendO11yApp is a Frontend Observability app
+type FrontendO11yApp struct {
+	ID                 int64  `json:"id,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Key                string `json:"appKey,omitempty"`
+	CollectEndpointURL string `json:"collectEndpointURL,omitempty"`
+}
+
+// faroEndpointURLsRegionExceptions contains hardcoded URLs for specific regions
+var faroEndpointURLsRegionExceptions = map[string]string{
+	"au":       "https://faro-api-prod-au-southeast-0.grafana.net/faro",
+	"eu":       "https://faro-api-prod-eu-west-0.grafana.net/faro",
+	"us-azure": "https://faro-api-prod-us-central-7.grafana.net/faro",
+	"us":       "https://faro-api-prod-us-central-0.grafana.net/faro",
+}
+
+type faroEndpointURLRegionCutoff struct {
+	cutoffDate      time.Time
+	faroEndpointURL string // URL to use after the cutoff date
+}
+
+var faroEndpointURLsAfterCutoff = map[string]faroEndpointURLRegionCutoff{
+	"prod-us-east-0": {
+		cutoffDate:      time.Date(2024, 12, 18, 0, 0, 0, 0, time.UTC),
+		faroEndpointURL: "https://faro-api-prod-us-east-2.grafana.net/faro",
+	},
+}
+
+// NewFrontendO11yClient creates a Frontend Observability client, the API host is derived from
+// the Grafana Cloud API URL unless the API URL is set
+func NewFrontendO11yClient(apiURL, cloudAPIURL, token string) (*FrontendO11yClient, error) {
+	host := cloudAPIURL
+	if i := strings.Index(host, "://"); i != -1 {
+		host = host[i+3:]
+	}
+	host, _, _ = strings.Cut(host, "/")
+
+	parts := strings.Split(host, ".")
+	if len(parts) < 2 {
+		return nil, errors.Errorf("invalid cloud url: %s", cloudAPIURL)
+	}
+
+	return &FrontendO11yClient{
+		APIURL: apiURL,
+		// https://grafana.com -> grafana.net
+		CloudAPIHost: parts[len(parts)-2] + ".net",
+		Token:        token,
+		HTTPClient:   http.DefaultClient,
+	}, nil
+}
+
+// FaroEndpointURL returns the Faro API endpoint URL for a given region and stack creation date
+func (c *FrontendO11yClient) FaroEndpointURL(regionSlug string, createdAt time.Time) string {
+	// The URL is manually supplied.
+	if c.APIURL != "" {
+		return c.APIURL
+	}
+
+	if cutoffInfo, ok := faroEndpointURLsAfterCutoff[regionSlug]; ok {
+		if createdAt.After(cutoffInfo.cutoffDate) {
+			return cutoffInfo.faroEndpointURL
+		}
+	}
+
+	if url, ok := faroEndpointURLsRegionExceptions[regionSlug]; ok {
+		return url
+	}
+
+	return fmt.Sprintf("https://faro-api-%s.%s/faro", regionSlug, c.CloudAPIHost)
+}
+
+// GetApps lists the apps of a stack
+func (c *FrontendO11yClient) GetApps(ctx context.Context, baseURL string, stackID int64) ([]FrontendO11yApp, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(baseURL, "/")+"/api/v1/app", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request")
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %d:%s", stackID, c.Token))
+	req.Header.Set("X-Scope-OrgID", fmt.Sprintf("%d", stackID))
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get faro apps")
+	}
+	defer resp.Body.Close() //nolint:errcheck // only read from
+
+	if resp.StatusCode >= 400 {
+		return nil, errors.Errorf("failed to get faro apps: status: %d", resp.StatusCode)
+	}
+
+	var apps []FrontendO11yApp
+	if err := json.NewDecoder(resp.Body).Decode(&apps); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal response body")
+	}
+	return apps, nil
+}
@@ -0,0 +1,292 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+	"github.com/crossplane/function-sdk-go/errors"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/request"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const (
+	// annotationPrefix is the prefix of the annotations this Function records on composed resources
+	annotationPrefix = "grafana.fn.crossplane.io/"
+
+	annotationPluginVersion        = annotationPrefix + "plugin-version"
+	annotationPluginVersionChecked = annotationPrefix + "plugin-version-checked"
+
+	pluginVersionLatest = "latest"
+
+	// defaultPluginVersionTTL is how long a resolved plugin version is kept before the plugin
+	// catalog is checked for a newer version
+	defaultPluginVersionTTL = 24 * time.Hour
+
+	// defaultPluginVersionTimeout bounds listing the versions of a plugin, the HTTP client of the
+	// Grafana Cloud API may have no timeout of its own
+	defaultPluginVersionTimeout = 30 * time.Second
+)
+
+// keepPluginVersionAnnotations copies the resolved plugin version and its check time from the
+// observed to the desired PluginInstallations, a previous Function in the pipeline only renders
+// the spec, so without them every invocation would resolve the version again
+func keepPluginVersionAnnotations(req *fnv1.RunFunctionRequest, desiredComposed map[resource.Name]*resource.DesiredComposed) error {
+	observedComposed, err := request.GetObservedComposedResources(req)
+	if err != nil {
+		return err
+	}
+
+	for name, desired := range desiredComposed {
+		gvk := desired.Resource.GroupVersionKind()
+		if gvk.Group != "cloud.grafana.crossplane.io" || gvk.Kind != "PluginInstallation" {
+			continue
+		}
+		observed, ok := observedComposed[name]
+		if !ok {
+			continue
+		}
+
+		annotations := desired.Resource.GetAnnotations()
+		for _, key := range []string{annotationPluginVersion, annotationPluginVersionChecked} {
+			value, ok := observed.Resource.GetAnnotations()[key]
+			if !ok {
+				continue
+			}
+			if _, ok := annotations[key]; ok {
+				continue
+			}
+			if annotations == nil {
+				annotations = map[string]string{}
+			}
+			annotations[key] = value
+		}
+		if annotations != nil {
+			desired.Resource.SetAnnotations(annotations)
+		}
+	}
+	return nil
+}
+
+// pluginVersions is the response of the plugin catalog versions endpoint
+type pluginVersions struct {
+	Items []struct {
+		Version string `json:"version"`
+	} `json:"items"`
+}
+
+// GetPluginInstallationVersion resolves the version of a plugin installation, "latest", a range
+// like "^3.2" or "~3.2.1", or a pinned version, against the plugin catalog, the resolved version
+// is recorded in annotations and kept until it no longer satisfies the range or the TTL expires
+func (c *CloudClient) GetPluginInstallationVersion(desired *resource.DesiredComposed) error {
+	var slug, spec string
+	if err := desired.Resource.GetValueInto("spec.forProvider.slug", &slug); err != nil {
+		return nil
+	}
+	if err := desired.Resource.GetValueInto("spec.forProvider.version", &spec); err != nil {
+		return nil
+	}
+
+	v, found, err := lookupReference(spec, func(spec string) (string, error) {
+		return c.resolvePluginVersion(desired, slug, spec)
+	})
+	if err != nil {
+		return err
+	}
+	// without a version the latest version is installed
+	if !found {
+		return fieldpath.Pave(desired.Resource.Object).DeleteField("spec.forProvider.version")
+	}
+	return desired.Resource.SetValue("spec.forProvider.version", v)
+}
+
+// resolvePluginVersion resolves a version of a plugin and records it in the annotations
+func (c *CloudClient) resolvePluginVersion(desired *resource.DesiredComposed, slug, spec string) (string, error) {
+	constraints, err := pluginVersionConstraints(spec)
+	if err != nil {
+		return spec, errors.Wrapf(err, "Invalid version %q of plugin %s", spec, slug)
+	}
+
+	annotations := desired.Resource.GetAnnotations()
+	if v, ok := c.cachedPluginVersion(annotations, constraints); ok {
+		return v, nil
+	}
+
+	v, err := c.GetPluginVersion(slug, constraints)
+	if err != nil {
+		return spec, err
+	}
+
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[annotationPluginVersion] = v
+	annotations[annotationPluginVersionChecked] = c.now().UTC().Format(time.RFC3339)
+	desired.Resource.SetAnnotations(annotations)
+	return v, nil
+}
+
+// cachedPluginVersion returns the recorded version if it satisfies the constraints and was
+// checked within the TTL
+func (c *CloudClient) cachedPluginVersion(annotations map[string]string, constraints version.Constraints) (string, bool) {
+	cached, err := version.NewVersion(annotations[annotationPluginVersion])
+	if err != nil || !constraints.Check(cached) {
+		return "", false
+	}
+	checked, err := time.Parse(time.RFC3339, annotations[annotationPluginVersionChecked])
+	if err != nil || c.now().Sub(checked) >= c.PluginVersionTTL {
+		return "", false
+	}
+	return cached.Original(), true
+}
+
+// GetPluginVersion returns the highest version of a plugin in the catalog that satisfies the
+// constraints, no constraints means the latest version
+func (c *CloudClient) GetPluginVersion(slug string, constraints version.Constraints) (string, error) {
+	versions, err := c.getPluginVersions(slug)
+	if err != nil {
+		return "", err
+	}
+
+	for _, v := range versions {
+		// prereleases only match when a constraint asks for them
+		if len(constraints) == 0 && v.Prerelease() != "" {
+			continue
+		}
+		if constraints.Check(v) {
+			return v.Original(), nil
+		}
+	}
+	return "", errors.Errorf("Could not find a version of plugin %s that satisfies %s", slug, constraints)
+}
+
+// getPluginVersions lists the versions of a plugin in the catalog, highest first
+func (c *CloudClient) getPluginVersions(slug string) ([]*version.Version, error) {
+	// only populate the list if the list is empty
+	if versions, ok := c.PluginVersions[slug]; ok {
+		return versions, nil
+	}
+
+	// the generated client has no endpoint for the plugin versions, call it with its configuration
+	cfg := c.Client.GetConfig()
+	serverURL, err := cfg.ServerURL(0, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid Grafana Cloud API URL")
+	}
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "Invalid Grafana Cloud API URL")
+	}
+	if cfg.Host != "" {
+		u.Host = cfg.Host
+	}
+	if cfg.Scheme != "" {
+		u.Scheme = cfg.Scheme
+	}
+	u = u.JoinPath("plugins", slug, "versions")
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.PluginVersionTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list versions of plugin %s", slug)
+	}
+	req.Header.Set("Accept", "application/json")
+	for header, value := range cfg.DefaultHeader {
+		req.Header.Set(header, value)
+	}
+
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to list versions of plugin %s", slug)
+	}
+	defer resp.Body.Close() //nolint:errcheck // only read from
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("Failed to list versions of plugin %s: %s", slug, resp.Status)
+	}
+
+	var body pluginVersions
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, errors.Wrapf(err, "Failed to list versions of plugin %s", slug)
+	}
+
+	versions := []*version.Version{}
+	for _, item := range body.Items {
+		// skip versions that are not semver, they cannot match a range
+		v, err := version.NewSemver(item.Version)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	slices.SortFunc(versions, func(a, b *version.Version) int {
+		return b.Compare(a)
+	})
+
+	c.PluginVersions[slug] = versions
+	return versions, nil
+}
+
+// pluginVersionConstraints translates "latest", caret and tilde ranges and pinned versions into
+// version constraints, other constraints such as ">= 3.2, < 4" are passed on as-is
+func pluginVersionConstraints(spec string) (version.Constraints, error) {
+	spec = strings.TrimSpace(spec)
+	switch {
+	case spec == "" || spec == pluginVersionLatest:
+		return version.Constraints{}, nil
+	case strings.HasPrefix(spec, "^"), strings.HasPrefix(spec, "~") && !strings.HasPrefix(spec, "~>"):
+		return rangeConstraints(spec[:1], spec[1:])
+	}
+	if v, err := version.NewSemver(spec); err == nil {
+		return version.NewConstraint("= " + v.Original())
+	}
+	return version.NewConstraint(spec)
+}
+
+// rangeConstraints translates a caret or tilde range into a lower and an upper bound, "^" allows
+// changes that do not modify the left-most non-zero part, "~" allows patch level changes when a
+// minor version is given and minor level changes otherwise
+func rangeConstraints(op, spec string) (version.Constraints, error) {
+	parts := strings.Split(spec, ".")
+	if len(parts) > 3 {
+		return nil, errors.Errorf("Malformed range: %s%s", op, spec)
+	}
+	nums := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, errors.Errorf("Malformed range: %s%s", op, spec)
+		}
+		nums[i] = n
+	}
+
+	upper := make([]int, 3)
+	switch {
+	case op == "~" && len(parts) > 1, op == "^" && nums[0] == 0 && len(parts) > 1 && (nums[1] != 0 || len(parts) == 2):
+		upper[0], upper[1] = nums[0], nums[1]+1
+	case op == "^" && nums[0] == 0 && len(parts) == 3:
+		upper[0], upper[1], upper[2] = nums[0], nums[1], nums[2]+1
+	default:
+		upper[0] = nums[0] + 1
+	}
+
+	return version.NewConstraint(">= " + joinVersion(nums) + ", < " + joinVersion(upper))
+}
+
+func joinVersion(nums []int) string {
+	return strconv.Itoa(nums[0]) + "." + strconv.Itoa(nums[1]) + "." + strconv.Itoa(nums[2])
+}
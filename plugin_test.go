@@ -0,0 +1,239 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/grafana-com-public-clients/go/gcom"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestCloudPluginInstallationVersion(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins/grafana-clock-panel/versions", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"items": []map[string]any{
+				{"version": "4.0.0-beta.1"},
+				{"version": "3.3.1"},
+				{"version": "3.2.7"},
+				{"version": "3.2.5"},
+				{"version": "3.1.0"},
+				{"version": "2.1.8"},
+			},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := gcom.NewConfiguration()
+	cfg.Servers = gcom.ServerConfigurations{{URL: srv.URL}}
+
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	checked := now.Format(time.RFC3339)
+
+	cases := map[string]struct {
+		reason      string
+		version     string
+		annotations map[string]string
+		want        string
+		wantChecked string
+		err         bool
+	}{
+		"Latest": {
+			reason:      "latest should resolve to the highest stable version",
+			version:     "latest",
+			want:        "3.3.1",
+			wantChecked: checked,
+		},
+		"Caret": {
+			reason:      "A caret range should resolve to the highest version with the same major version",
+			version:     "^3.2",
+			want:        "3.3.1",
+			wantChecked: checked,
+		},
+		"Tilde": {
+			reason:      "A tilde range should resolve to the highest version with the same minor version",
+			version:     "~3.2.1",
+			want:        "3.2.7",
+			wantChecked: checked,
+		},
+		"Pinned": {
+			reason:      "A pinned version should be checked against the catalog",
+			version:     "3.2.5",
+			want:        "3.2.5",
+			wantChecked: checked,
+		},
+		"UnknownPinned": {
+			reason:  "A pinned version that is not in the catalog should return an error",
+			version: "3.2.6",
+			err:     true,
+		},
+		"Fallback": {
+			reason:  "A version that cannot be resolved should be replaced by its fallback",
+			version: "3.2.6 ?? 3.2.5",
+			want:    "3.2.5",
+		},
+		"Cached": {
+			reason:  "A recorded version within the TTL that satisfies the range should be kept",
+			version: "^3.2",
+			annotations: map[string]string{
+				annotationPluginVersion:        "3.2.5",
+				annotationPluginVersionChecked: now.Add(-time.Hour).Format(time.RFC3339),
+			},
+			want:        "3.2.5",
+			wantChecked: now.Add(-time.Hour).Format(time.RFC3339),
+		},
+		"Expired": {
+			reason:  "A recorded version past the TTL should be resolved again",
+			version: "^3.2",
+			annotations: map[string]string{
+				annotationPluginVersion:        "3.2.5",
+				annotationPluginVersionChecked: now.Add(-25 * time.Hour).Format(time.RFC3339),
+			},
+			want:        "3.3.1",
+			wantChecked: checked,
+		},
+		"RangeChanged": {
+			reason:  "A recorded version that no longer satisfies the range should be resolved again",
+			version: "^2.0",
+			annotations: map[string]string{
+				annotationPluginVersion:        "3.2.5",
+				annotationPluginVersionChecked: now.Add(-time.Hour).Format(time.RFC3339),
+			},
+			want:        "2.1.8",
+			wantChecked: checked,
+		},
+	}
+
+	c := NewCloudClient(gcom.NewAPIClient(cfg))
+	c.now = func() time.Time { return now }
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "cloud.grafana.crossplane.io/v1alpha1", "kind": "PluginInstallation", "spec": {"forProvider": {"slug": "grafana-clock-panel", "version": "` + tc.version + `"}}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+			desired.Resource.SetAnnotations(tc.annotations)
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetString("spec.forProvider.version")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+
+			// a fallback is not recorded
+			var want map[string]string
+			if tc.wantChecked != "" {
+				want = map[string]string{
+					annotationPluginVersion:        tc.want,
+					annotationPluginVersionChecked: tc.wantChecked,
+				}
+			}
+			if diff := cmp.Diff(want, desired.Resource.GetAnnotations()); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want annotations, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestCloudPluginVersionsTimeout(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/plugins/grafana-clock-panel/versions", func(_ http.ResponseWriter, r *http.Request) {
+		// the catalog never answers, the request is only ended by the timeout
+		<-r.Context().Done()
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := gcom.NewConfiguration()
+	cfg.Servers = gcom.ServerConfigurations{{URL: srv.URL}}
+
+	c := NewCloudClient(gcom.NewAPIClient(cfg))
+	c.PluginVersionTimeout = 50 * time.Millisecond
+
+	if _, err := c.getPluginVersions("grafana-clock-panel"); err == nil {
+		t.Error("c.getPluginVersions(...): expected an error when the catalog does not answer in time")
+	}
+}
+
+func TestKeepPluginVersionAnnotations(t *testing.T) {
+	observed := `{"apiVersion": "cloud.grafana.crossplane.io/v1alpha1", "kind": "PluginInstallation", "metadata": {"annotations": {"grafana.fn.crossplane.io/plugin-version": "3.3.1", "grafana.fn.crossplane.io/plugin-version-checked": "2025-06-01T12:00:00Z", "example.org/other": "kept-out"}}}`
+	observedFolder := `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Folder", "metadata": {"annotations": {"grafana.fn.crossplane.io/plugin-version": "3.3.1"}}}`
+
+	req := &fnv1.RunFunctionRequest{
+		Observed: &fnv1.State{
+			Resources: map[string]*fnv1.Resource{
+				"plugin": {Resource: resource.MustStructJSON(observed)},
+				"pinned": {Resource: resource.MustStructJSON(observed)},
+				"folder": {Resource: resource.MustStructJSON(observedFolder)},
+			},
+		},
+	}
+
+	cases := map[string]struct {
+		reason string
+		obj    string
+		want   map[string]string
+	}{
+		"plugin": {
+			reason: "The plugin version annotations of an observed PluginInstallation should be kept",
+			obj:    `{"apiVersion": "cloud.grafana.crossplane.io/v1alpha1", "kind": "PluginInstallation"}`,
+			want: map[string]string{
+				"grafana.fn.crossplane.io/plugin-version":         "3.3.1",
+				"grafana.fn.crossplane.io/plugin-version-checked": "2025-06-01T12:00:00Z",
+			},
+		},
+		"pinned": {
+			reason: "Annotations set on the desired resource should take precedence",
+			obj:    `{"apiVersion": "cloud.grafana.crossplane.io/v1alpha1", "kind": "PluginInstallation", "metadata": {"annotations": {"grafana.fn.crossplane.io/plugin-version": "3.2.7"}}}`,
+			want: map[string]string{
+				"grafana.fn.crossplane.io/plugin-version":         "3.2.7",
+				"grafana.fn.crossplane.io/plugin-version-checked": "2025-06-01T12:00:00Z",
+			},
+		},
+		"folder": {
+			reason: "Other kinds should not get annotations from their observed resources",
+			obj:    `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Folder"}`,
+			want:   nil,
+		},
+	}
+
+	desiredComposed := map[resource.Name]*resource.DesiredComposed{}
+	for name, tc := range cases {
+		desired := &resource.DesiredComposed{Resource: composed.New()}
+		if err := desired.Resource.UnmarshalJSON([]byte(tc.obj)); err != nil {
+			t.Fatal(err)
+		}
+		desiredComposed[resource.Name(name)] = desired
+	}
+
+	if err := keepPluginVersionAnnotations(req, desiredComposed); err != nil {
+		t.Fatalf("keepPluginVersionAnnotations(...): unexpected error: %v", err)
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := desiredComposed[resource.Name(name)].Resource.GetAnnotations()
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nkeepPluginVersionAnnotations(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
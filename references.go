@@ -0,0 +1,242 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/crossplane/crossplane-runtime/v2/pkg/fieldpath"
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const (
+	// referenceOptionalPrefix marks a reference that is removed when it cannot be resolved
+	referenceOptionalPrefix = "optional:"
+	// referenceFallbackSeparator separates a reference from the value that replaces it when it
+	// cannot be resolved, e.g. "platform ?? 7"
+	referenceFallbackSeparator = " ?? "
+)
+
+// reference is a reference to look up with its modifiers
+type reference struct {
+	Name     string
+	Optional bool
+	Fallback *string
+}
+
+// parseReference returns the reference of a string with its modifiers
+func parseReference(s string) reference {
+	ref := reference{Name: s}
+	if name, fallback, ok := strings.Cut(ref.Name, referenceFallbackSeparator); ok {
+		ref.Name = name
+		ref.Fallback = &fallback
+	}
+	if name, ok := strings.CutPrefix(ref.Name, referenceOptionalPrefix); ok {
+		ref.Name = name
+		ref.Optional = true
+	}
+	return ref
+}
+
+// hasModifiers returns true for an optional reference or a reference with a fallback
+func (r reference) hasModifiers() bool {
+	return r.Optional || r.Fallback != nil
+}
+
+// isModifiedReference returns true for a string with reference modifiers
+func isModifiedReference(v any) bool {
+	s, ok := v.(string)
+	return ok && parseReference(s).hasModifiers()
+}
+
+// convertValue converts a value to another type through JSON
+func convertValue(from, to any) error {
+	b, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, to)
+}
+
+// fallbackValue converts a fallback to the type of a resolved value, a fallback for a list
+// becomes a single item and a fallback for a number is parsed
+func fallbackValue[W any](fallback string) (W, error) {
+	var val W
+	quoted, _ := json.Marshal(fallback)
+	for _, candidate := range []string{string(quoted), fallback, "[" + string(quoted) + "]", "[" + fallback + "]"} {
+		if err := json.Unmarshal([]byte(candidate), &val); err == nil {
+			return val, nil
+		}
+	}
+	return val, errors.Errorf("Fallback %s does not fit %T", fallback, val)
+}
+
+// replaceReferencePath replaces the reference or list of references at a path like replacePath,
+// references may carry modifiers, free-text and document fields must use replacePath instead
+func replaceReferencePath[V, W any](desired *resource.DesiredComposed, path string, fn func(V) (W, error)) error {
+	var raw any
+	if err := fieldpath.Pave(desired.Resource.Object).GetValueInto(path, &raw); err == nil {
+		switch v := raw.(type) {
+		case string:
+			if ref := parseReference(v); ref.hasModifiers() {
+				return replaceReference(desired, path, ref, fn)
+			}
+		case []any:
+			if slices.ContainsFunc(v, isModifiedReference) {
+				return replaceReferences(desired, path, v, fn)
+			}
+		}
+	}
+	return replacePath(desired, path, fn)
+}
+
+// lookupReference looks up a reference with modifiers in an object field, found is false for an
+// optional reference that cannot be resolved
+func lookupReference(s string, fn func(string) (string, error)) (newVal string, found bool, err error) {
+	ref := parseReference(s)
+	newVal, err = fn(ref.Name)
+	if err != nil {
+		switch {
+		case ref.Fallback != nil:
+			return *ref.Fallback, true, nil
+		case ref.Optional:
+			return "", false, nil
+		}
+		return newVal, false, err
+	}
+	return newVal, true, nil
+}
+
+// lookupReferences looks up a reference with modifiers that may expand to several values, an
+// optional reference that cannot be resolved expands to no values
+func lookupReferences(s string, fn func(string) ([]string, error)) ([]string, error) {
+	ref := parseReference(s)
+	newVals, err := fn(ref.Name)
+	if err != nil {
+		switch {
+		case ref.Fallback != nil:
+			return []string{*ref.Fallback}, nil
+		case ref.Optional:
+			return nil, nil
+		}
+		return nil, err
+	}
+	return newVals, nil
+}
+
+// replaceReferenceKey looks up the reference at a key of an object, an optional reference that
+// cannot be resolved removes the key
+func replaceReferenceKey(obj map[string]any, key string, fn func(string) (string, error)) error {
+	s, ok := obj[key].(string)
+	if !ok {
+		return nil
+	}
+
+	newVal, found, err := lookupReference(s, fn)
+	if err != nil {
+		return err
+	}
+	if !found {
+		delete(obj, key)
+		return nil
+	}
+	obj[key] = newVal
+	return nil
+}
+
+// replaceReferenceItems looks up the references in a list of strings, optional references that
+// cannot be resolved are removed from the list
+func replaceReferenceItems(items []any, fn func(string) (string, error)) ([]any, error) {
+	newItems := make([]any, 0, len(items))
+	for _, item := range items {
+		s, ok := item.(string)
+		if !ok {
+			newItems = append(newItems, item)
+			continue
+		}
+
+		newVal, found, err := lookupReference(s, fn)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			newItems = append(newItems, newVal)
+		}
+	}
+	return newItems, nil
+}
+
+// replaceReference looks up a reference with modifiers in a string field, an optional reference
+// that cannot be resolved is removed and a fallback replaces a reference that cannot be resolved
+func replaceReference[V, W any](desired *resource.DesiredComposed, path string, ref reference, fn func(V) (W, error)) error {
+	var val V
+	if err := convertValue(ref.Name, &val); err != nil {
+		return errors.Wrapf(err, "cannot convert reference %s", ref.Name)
+	}
+
+	newVal, err := fn(val)
+	if err != nil {
+		switch {
+		case ref.Fallback != nil:
+			if newVal, err = fallbackValue[W](*ref.Fallback); err != nil {
+				return err
+			}
+		case ref.Optional:
+			return fieldpath.Pave(desired.Resource.Object).DeleteField(path)
+		default:
+			return err
+		}
+	}
+
+	if err := desired.Resource.SetValue(path, newVal); err != nil {
+		gvk := desired.Resource.GroupVersionKind()
+		return errors.Wrapf(err, "cannot set value for %s", gvk.Kind)
+	}
+	return nil
+}
+
+// replaceReferences looks up a list of references with modifiers, the modifiers are removed first
+// and the list is looked up in one call so it is sorted and deduplicated like a list without
+// modifiers, optional references that cannot be resolved are dropped and fallbacks are looked up in
+// place of references that cannot be resolved
+func replaceReferences[V, W any](desired *resource.DesiredComposed, path string, list []any, fn func(V) (W, error)) error {
+	items := make([]any, 0, len(list))
+	for _, item := range list {
+		s, ok := item.(string)
+		if !ok || !parseReference(s).hasModifiers() {
+			items = append(items, item)
+			continue
+		}
+
+		// a reference with modifiers is checked on its own, so it does not fail the list
+		ref := parseReference(s)
+		var val V
+		if err := convertValue([]any{ref.Name}, &val); err != nil {
+			return errors.Wrapf(err, "cannot convert reference %s", ref.Name)
+		}
+		if _, err := fn(val); err == nil {
+			items = append(items, ref.Name)
+			continue
+		}
+		if ref.Fallback != nil {
+			items = append(items, *ref.Fallback)
+		}
+	}
+
+	var val V
+	if err := convertValue(items, &val); err != nil {
+		return errors.Wrapf(err, "cannot convert references %v", items)
+	}
+
+	newVal, err := fn(val)
+	if err != nil {
+		return err
+	}
+
+	if err := desired.Resource.SetValue(path, newVal); err != nil {
+		gvk := desired.Resource.GroupVersionKind()
+		return errors.Wrapf(err, "cannot set value for %s", gvk.Kind)
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestReplaceReferencePath(t *testing.T) {
+	teams := map[string]string{"platform": "7", "payments": "9", "sre": "3"}
+	getTeam := func(name string) (string, error) {
+		if id, ok := teams[name]; ok {
+			return id, nil
+		}
+		for _, id := range teams {
+			if id == name {
+				return id, nil
+			}
+		}
+		return name, errors.Errorf("Could not find ID for team: %s", name)
+	}
+	getTeams := func(names []string) ([]string, error) {
+		ids := []string{}
+		for _, name := range names {
+			id, err := getTeam(name)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	getProbes := func(names []string) ([]int64, error) {
+		ids := []int64{}
+		for _, name := range names {
+			id, err := getTeam(name)
+			if err != nil {
+				return nil, err
+			}
+			i, _ := strconv.ParseInt(id, 10, 64)
+			ids = append(ids, i)
+		}
+		return ids, nil
+	}
+
+	cases := map[string]struct {
+		reason  string
+		val     string
+		process func(desired *resource.DesiredComposed) error
+		want    map[string]any
+		err     bool
+	}{
+		"OptionalField": {
+			reason: "An optional reference that cannot be resolved should remove the field",
+			val:    `{"teamId": "optional:search", "name": "example"}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teamId", getTeam)
+			},
+			want: map[string]any{"name": "example"},
+		},
+		"OptionalFieldResolved": {
+			reason: "An optional reference that can be resolved should be replaced",
+			val:    `{"teamId": "optional:platform"}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teamId", getTeam)
+			},
+			want: map[string]any{"teamId": "7"},
+		},
+		"FallbackField": {
+			reason: "A reference that cannot be resolved should be replaced by its fallback",
+			val:    `{"teamId": "search ?? 1"}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teamId", getTeam)
+			},
+			want: map[string]any{"teamId": "1"},
+		},
+		"RequiredField": {
+			reason: "A reference without modifiers that cannot be resolved should return an error",
+			val:    `{"teamId": "search"}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teamId", getTeam)
+			},
+			err: true,
+		},
+		"OptionalListItem": {
+			reason: "An optional reference that cannot be resolved should be removed from the list",
+			val:    `{"teams": ["platform", "optional:search", "payments"]}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teams", getTeams)
+			},
+			want: map[string]any{"teams": []any{"7", "9"}},
+		},
+		"FallbackListItem": {
+			reason: "A fallback in a list should be looked up with the other items",
+			val:    `{"probes": ["search ?? 3", "platform"]}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.probes", getProbes)
+			},
+			want: map[string]any{"probes": []any{int64(3), int64(7)}},
+		},
+		"RequiredListItem": {
+			reason: "A list item without modifiers that cannot be resolved should return an error",
+			val:    `{"teams": ["optional:platform", "search"]}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replaceReferencePath(desired, "spec.forProvider.teams", getTeams)
+			},
+			err: true,
+		},
+		"OptionalValue": {
+			reason: "An optional reference in a typed field that cannot be resolved should be removed",
+			val:    `{"permissions": [{"teamId": "optional:search", "permission": "View"}, {"teamId": "platform", "permission": "Edit"}]}`,
+			process: func(desired *resource.DesiredComposed) error {
+				return replacePath(desired, "spec.forProvider.permissions", func(permissions []map[string]*string) ([]map[string]*string, error) {
+					for _, p := range permissions {
+						teamID := p["teamId"]
+						if err := replaceValue(&teamID, getTeam); err != nil {
+							return nil, err
+						}
+						p["teamId"] = teamID
+					}
+					return permissions, nil
+				})
+			},
+			want: map[string]any{"permissions": []any{
+				map[string]any{"teamId": nil, "permission": "View"},
+				map[string]any{"teamId": "7", "permission": "Edit"},
+			}},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Example", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tc.process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nreplaceReferencePath(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nreplaceReferencePath(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestReplaceReferenceKey(t *testing.T) {
+	getDashboardUID := func(name string) (string, error) {
+		if name == "Overview" {
+			return "overview-uid", nil
+		}
+		return name, errors.Errorf("Could not find dashboard with title: %s", name)
+	}
+
+	cases := map[string]struct {
+		reason string
+		obj    map[string]any
+		want   map[string]any
+		err    bool
+	}{
+		"Resolved": {
+			reason: "A reference that can be resolved should be replaced",
+			obj:    map[string]any{"uid": "optional:Overview"},
+			want:   map[string]any{"uid": "overview-uid"},
+		},
+		"Optional": {
+			reason: "An optional reference that cannot be resolved should remove the key",
+			obj:    map[string]any{"uid": "optional:Unknown", "theme": "dark"},
+			want:   map[string]any{"theme": "dark"},
+		},
+		"Fallback": {
+			reason: "A reference that cannot be resolved should be replaced by its fallback",
+			obj:    map[string]any{"uid": "Unknown ?? home"},
+			want:   map[string]any{"uid": "home"},
+		},
+		"Required": {
+			reason: "A reference without modifiers that cannot be resolved should return an error",
+			obj:    map[string]any{"uid": "Unknown"},
+			err:    true,
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			err := replaceReferenceKey(tc.obj, "uid", getDashboardUID)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nreplaceReferenceKey(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+			if diff := cmp.Diff(tc.want, tc.obj); diff != "" {
+				t.Errorf("%s\nreplaceReferenceKey(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// resolver resolves references in the fields of desired composed resources
+type resolver interface {
+	Process(desired *resource.DesiredComposed) error
+}
+
+// newResolvers returns the resolvers by API group for the clients of a providerConfig, the
+// resolvers share their clients so each lookup only happens once per Function invocation
+func newResolvers(cs *clients.Client, in *v1beta1.Input, composedAlerting map[string][]string) map[string]resolver {
+	grafana := NewGrafanaClient(cs.GrafanaAPI)
+
+	// the Grafana client is optional for OnCall lookups
+	oncallGrafana := grafana
+	if cs.GrafanaAPI == nil {
+		oncallGrafana = nil
+	}
+	oncall := NewOnCallClient(cs.OnCallClient, oncallGrafana, in.Channels)
+
+	alerting := NewAlertingClient(grafana, oncall, in.Alerting)
+	alerting.Composed = composedAlerting
+
+	// the Grafana Cloud client is optional for installations, which refer to a stack
+	var cloud *CloudClient
+	if cs.GrafanaCloudAPI != nil {
+		cloud = NewCloudClient(cs.GrafanaCloudAPI)
+		if in.Plugins.VersionCacheTTL != nil {
+			cloud.PluginVersionTTL = in.Plugins.VersionCacheTTL.Duration
+		}
+	}
+
+	sm := NewSMClient(cs.SMAPI)
+	sm.Cloud = cloud
+
+	k6 := NewK6Client(cs.K6APIClient, cs.K6APIConfig)
+	k6.Cloud = cloud
+
+	resolvers := map[string]resolver{
+		"oncall.grafana.crossplane.io":                oncall,
+		"sm.grafana.crossplane.io":                    sm,
+		"oss.grafana.crossplane.io":                   grafana,
+		"enterprise.grafana.crossplane.io":            grafana,
+		"alerting.grafana.crossplane.io":              alerting,
+		"frontendobservability.grafana.crossplane.io": NewFrontendO11yClient(cs.FrontendO11yAPIClient, cloud),
+	}
+
+	// these clients are only created when the providerConfig has credentials for them
+	if cs.SLOClient != nil {
+		resolvers["slo.grafana.crossplane.io"] = NewSLOClient(cs.SLOClient, grafana, alerting)
+	}
+	if cs.MLAPI != nil {
+		resolvers["ml.grafana.crossplane.io"] = NewMLClient(cs.MLAPI, grafana)
+	}
+	if cs.K6APIClient != nil {
+		resolvers["k6.grafana.crossplane.io"] = k6
+	}
+	if cs.AssertsAPIClient != nil {
+		resolvers["asserts.grafana.crossplane.io"] = NewAssertsClient(cs.AssertsAPIClient, grafana, cs.GrafanaStackID)
+	}
+	if cs.FleetManagementClient != nil {
+		resolvers["fleetmanagement.grafana.crossplane.io"] = NewFleetManagementClient(cs.FleetManagementClient)
+	}
+	if cloud != nil {
+		resolvers["cloud.grafana.crossplane.io"] = cloud
+	}
+	return resolvers
+}
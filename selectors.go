@@ -0,0 +1,224 @@
+package main
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/grafana-openapi-client-go/client/org"
+	"github.com/grafana/grafana-openapi-client-go/client/service_accounts"
+	"github.com/grafana/grafana-openapi-client-go/client/teams"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/errors"
+)
+
+const (
+	selectorRegexPrefix  = "regex:"
+	selectorPrefixPrefix = "prefix:"
+	// selectorLabelPrefix is reserved for label selectors, Grafana teams, users and service
+	// accounts have no labels to match
+	selectorLabelPrefix = "label:"
+
+	// selectorMatchLimit fails a selector that matches unexpectedly many entities
+	selectorMatchLimit = 100
+)
+
+// isNameSelector returns true for a selector that expands to several entities instead of a name
+func isNameSelector(name string) bool {
+	return strings.HasPrefix(name, selectorRegexPrefix) ||
+		strings.HasPrefix(name, selectorPrefixPrefix) ||
+		strings.HasPrefix(name, selectorLabelPrefix)
+}
+
+// parseNameSelector returns a function that matches names against a regex: or prefix: selector
+func parseNameSelector(selector string) (func(string) bool, error) {
+	switch {
+	case strings.HasPrefix(selector, selectorRegexPrefix):
+		re, err := regexp.Compile(strings.TrimPrefix(selector, selectorRegexPrefix))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid selector %s", selector)
+		}
+		return re.MatchString, nil
+
+	case strings.HasPrefix(selector, selectorPrefixPrefix):
+		prefix := strings.TrimPrefix(selector, selectorPrefixPrefix)
+		return func(name string) bool {
+			return strings.HasPrefix(name, prefix)
+		}, nil
+
+	case strings.HasPrefix(selector, selectorLabelPrefix):
+		return nil, errors.Errorf("Unsupported selector %s, Grafana teams, users and service accounts have no labels, use a regex: or prefix: selector", selector)
+	}
+	return nil, errors.Errorf("Unknown selector %s", selector)
+}
+
+// selectIDs returns the sorted IDs of the items that a selector matches by any of their names
+func selectIDs[T any](kind, selector string, items []T, fn func(T) (int64, []string)) ([]string, error) {
+	match, err := parseNameSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int64{}
+	for _, item := range items {
+		id, names := fn(item)
+		if slices.ContainsFunc(names, match) {
+			ids = append(ids, id)
+		}
+	}
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+
+	switch {
+	case len(ids) == 0:
+		return nil, errors.Errorf("Selector %s matches no %s", selector, kind)
+	case len(ids) > selectorMatchLimit:
+		return nil, errors.Errorf("Selector %s matches %d %s, more than the limit of %d", selector, len(ids), kind, selectorMatchLimit)
+	}
+
+	newVal := make([]string, 0, len(ids))
+	for _, id := range ids {
+		newVal = append(newVal, strconv.FormatInt(id, 10))
+	}
+	return newVal, nil
+}
+
+// lookupNames looks up the ID of each name, a selector expands to the IDs of every match, the IDs
+// are deduplicated and sorted so the list does not change between runs
+func lookupNames(names []string, lookup func(string) (string, error), selectAll func(string) ([]string, error)) ([]string, error) {
+	newVal := []string{}
+	for _, name := range names {
+		if isNameSelector(name) {
+			ids, err := selectAll(name)
+			if err != nil {
+				return nil, err
+			}
+			newVal = append(newVal, ids...)
+			continue
+		}
+
+		id, err := lookup(name)
+		if err != nil {
+			return nil, err
+		}
+		newVal = append(newVal, id)
+	}
+
+	newVal = dedupe(newVal)
+	slices.SortFunc(newVal, compareIDs)
+	return newVal, nil
+}
+
+// compareIDs orders numeric IDs by value and other IDs after them by text
+func compareIDs(a, b string) int {
+	ia, errA := strconv.ParseInt(a, 10, 64)
+	ib, errB := strconv.ParseInt(b, 10, 64)
+	switch {
+	case errA == nil && errB == nil:
+		return cmp.Compare(ia, ib)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func (c *GrafanaClient) getTeams() error {
+	// only populate the list once, an organization without teams is listed as an empty list
+	if c.Teams != nil {
+		return nil
+	}
+
+	allTeams := []*models.TeamDTO{}
+	perPage := int64(1000)
+	page := int64(1)
+	for {
+		params := teams.NewSearchTeamsParams().WithPerpage(&perPage).WithPage(&page)
+		resp, err := c.Client.Teams.SearchTeams(params)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list teams")
+		}
+		allTeams = append(allTeams, resp.GetPayload().Teams...)
+
+		if int64(len(resp.GetPayload().Teams)) < perPage {
+			break
+		}
+		page++
+	}
+	c.Teams = allTeams
+	return nil
+}
+
+func (c *GrafanaClient) getUsers() error {
+	// only populate the list if the list is empty
+	if len(c.Users) != 0 {
+		return nil
+	}
+
+	resp, err := c.Client.Org.GetOrgUsersForCurrentOrg(org.NewGetOrgUsersForCurrentOrgParams())
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list users")
+	}
+	c.Users = resp.GetPayload()
+	return nil
+}
+
+func (c *GrafanaClient) getServiceAccounts() error {
+	// only populate the list if the list is empty
+	if len(c.ServiceAccounts) != 0 {
+		return nil
+	}
+
+	allServiceAccounts := []*models.ServiceAccountDTO{}
+	perPage := int64(1000)
+	page := int64(1)
+	for {
+		params := service_accounts.NewSearchOrgServiceAccountsWithPagingParams().WithPerpage(&perPage).WithPage(&page)
+		resp, err := c.Client.ServiceAccounts.SearchOrgServiceAccountsWithPaging(params)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to list service accounts")
+		}
+		allServiceAccounts = append(allServiceAccounts, resp.GetPayload().ServiceAccounts...)
+
+		if int64(len(resp.GetPayload().ServiceAccounts)) < perPage {
+			break
+		}
+		page++
+	}
+	c.ServiceAccounts = allServiceAccounts
+	return nil
+}
+
+// SelectTeams returns the IDs of the teams whose name matches a selector
+func (c *GrafanaClient) SelectTeams(selector string) ([]string, error) {
+	if err := c.getTeams(); err != nil {
+		return nil, err
+	}
+	return selectIDs("teams", selector, c.Teams, func(t *models.TeamDTO) (int64, []string) {
+		return t.ID, []string{t.Name}
+	})
+}
+
+// SelectUsers returns the IDs of the users whose login or email matches a selector
+func (c *GrafanaClient) SelectUsers(selector string) ([]string, error) {
+	if err := c.getUsers(); err != nil {
+		return nil, err
+	}
+	return selectIDs("users", selector, c.Users, func(u *models.OrgUserDTO) (int64, []string) {
+		return u.UserID, []string{u.Login, u.Email}
+	})
+}
+
+// SelectServiceAccounts returns the IDs of the service accounts whose name matches a selector
+func (c *GrafanaClient) SelectServiceAccounts(selector string) ([]string, error) {
+	if err := c.getServiceAccounts(); err != nil {
+		return nil, err
+	}
+	return selectIDs("service accounts", selector, c.ServiceAccounts, func(sa *models.ServiceAccountDTO) (int64, []string) {
+		return sa.ID, []string{sa.Name}
+	})
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/grafana/slo-openapi-client/go/slo"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+const (
+	sloContactPointPrefix = "contact-point:"
+	sloOnCallPrefix       = "oncall:"
+)
+
+// SLOClient is a client with convenience methods
+type SLOClient struct {
+	Client   *slo.APIClient
+	Grafana  *GrafanaClient
+	Alerting *AlertingClient
+	SLOs     []slo.SloV00Slo
+}
+
+// NewSLOClient returns a client with convenience methods for SLOs, Grafana and alerting
+func NewSLOClient(client *slo.APIClient, grafana *GrafanaClient, alerting *AlertingClient) *SLOClient {
+	return &SLOClient{
+		Client:   client,
+		Grafana:  grafana,
+		Alerting: alerting,
+	}
+}
+
+func (c *SLOClient) getSLOs() error {
+	// only populate the list if the list is empty
+	if len(c.SLOs) != 0 {
+		return nil
+	}
+
+	ctx := context.Background()
+	response, _, err := c.Client.DefaultAPI.V1SloGet(ctx).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "Failed to list SLOs")
+	}
+
+	c.SLOs = response.Slos
+	return nil
+}
+
+// Process processes fields of different kinds
+func (c *SLOClient) Process(desired *resource.DesiredComposed) error {
+	gvk := desired.Resource.GroupVersionKind()
+	if gvk.Kind == "SLO" {
+		path := "spec.forProvider.destinationDatasource"
+		if err := replacePath(desired, path, c.GetDestinationDatasources); err != nil {
+			return err
+		}
+
+		path = "spec.forProvider.folderUid"
+		if err := replaceReferencePath(desired, path, c.Grafana.GetFolderUID); err != nil {
+			return err
+		}
+
+		refs := &alertingReferences{client: c.Alerting, kind: gvk.Kind}
+		path = "spec.forProvider.alerting"
+		if err := replacePath(desired, path, func(alerting []map[string]any) ([]map[string]any, error) {
+			return c.alerting(refs, alerting), nil
+		}); err != nil {
+			return err
+		}
+		return refs.err()
+	}
+	return nil
+}
+
+// GetDestinationDatasources looks up the destination data source UIDs by name
+func (c *SLOClient) GetDestinationDatasources(datasources []map[string]any) ([]map[string]any, error) {
+	newDatasources := make([]map[string]any, 0, len(datasources))
+	for _, ds := range datasources {
+		if name, ok := ds["uid"].(string); ok {
+			uid, found, err := lookupReference(name, c.Grafana.GetDataSourceUID)
+			if err != nil {
+				return nil, err
+			}
+			if !found {
+				continue
+			}
+			ds["uid"] = uid
+		}
+		newDatasources = append(newDatasources, ds)
+	}
+	return newDatasources, nil
+}
+
+// alerting resolves the contact point and OnCall references in the labels of the alerting
+// sections, a label value "contact-point:<name>" is checked against the known contact points and
+// "oncall:<integration>" is replaced with the contact point name of the OnCall integration, a label
+// with an optional reference that cannot be resolved is removed
+func (c *SLOClient) alerting(refs *alertingReferences, alerting []map[string]any) []map[string]any {
+	for _, section := range alerting {
+		labelled := []map[string]any{section}
+		for _, key := range []string{"fastburn", "slowburn"} {
+			labelled = append(labelled, nestedObjects(section, key)...)
+		}
+
+		for _, obj := range labelled {
+			labels, ok := obj["label"].([]any)
+			if !ok {
+				continue
+			}
+			newLabels := make([]any, 0, len(labels))
+			for _, label := range labels {
+				if l, ok := label.(map[string]any); ok && !c.label(refs, l) {
+					continue
+				}
+				newLabels = append(newLabels, label)
+			}
+			obj["label"] = newLabels
+		}
+	}
+	return alerting
+}
+
+// label resolves the reference in the value of a label, it returns false for an optional
+// reference that cannot be resolved
+func (c *SLOClient) label(refs *alertingReferences, label map[string]any) bool {
+	value, _ := label["value"].(string)
+	var (
+		prefix string
+		fn     func(string) (string, error)
+	)
+	switch {
+	case strings.HasPrefix(value, sloContactPointPrefix):
+		prefix, fn = sloContactPointPrefix, c.Alerting.GetContactPointName
+	case strings.HasPrefix(value, sloOnCallPrefix):
+		prefix, fn = sloOnCallPrefix, c.GetOnCallContactPointName
+	default:
+		return true
+	}
+
+	newValue, found := refs.resolve(strings.TrimPrefix(value, prefix), fn)
+	label["value"] = newValue
+	return found
+}
+
+// GetOnCallContactPointName looks up an OnCall integration by name or "team/integration-name" and
+// returns its name, which is also the name of the contact point OnCall creates for it
+func (c *SLOClient) GetOnCallContactPointName(ref string) (string, error) {
+	selector, err := c.Alerting.OnCall.ParseIntegrationRef(ref)
+	if err != nil {
+		return ref, err
+	}
+	integration, err := c.Alerting.OnCall.FindIntegration(selector)
+	if err != nil {
+		return ref, err
+	}
+	return integration.Name, nil
+}
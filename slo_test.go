@@ -0,0 +1,124 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	onCallAPI "github.com/grafana/amixr-api-go-client"
+	"github.com/grafana/grafana-openapi-client-go/models"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestSLOProcess(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/datasources", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.DataSourceList{
+			{UID: "prom-uid", Name: "Prometheus", Type: "prometheus"},
+		})
+	})
+	mux.HandleFunc("/api/search", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, models.HitList{
+			{UID: "team-a", Title: "Team A"},
+		})
+	})
+	mux.HandleFunc("/api/v1/integrations/", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"count": 1,
+			"results": []onCallAPI.Integration{
+				{ID: "I1", Name: "checkout-alerts", Type: "grafana_alerting"},
+			},
+		})
+	})
+	oncall := newTestOnCallClient(t, mux)
+	alerting := &AlertingClient{
+		Grafana:       oncall.Grafana,
+		OnCall:        oncall,
+		ContactPoints: models.ContactPoints{{Name: "platform", UID: "cp-platform-uid"}},
+	}
+
+	cases := map[string]struct {
+		reason string
+		val    string
+		want   map[string]any
+		err    bool
+	}{
+		"References": {
+			reason: "The data source, folder and alerting label references should resolve",
+			val: `{
+				"destinationDatasource": [{"uid": "Prometheus"}],
+				"folderUid": "Team A",
+				"alerting": [{
+					"label": [{"key": "team", "value": "checkout"}, {"key": "contact", "value": "contact-point:platform"}],
+					"fastburn": [{"label": [{"key": "oncall", "value": "oncall:checkout-alerts"}]}]
+				}]
+			}`,
+			want: map[string]any{
+				"destinationDatasource": []any{map[string]any{"uid": "prom-uid"}},
+				"folderUid":             "team-a",
+				"alerting": []any{map[string]any{
+					"label": []any{
+						map[string]any{"key": "team", "value": "checkout"},
+						map[string]any{"key": "contact", "value": "platform"},
+					},
+					"fastburn": []any{map[string]any{"label": []any{
+						map[string]any{"key": "oncall", "value": "checkout-alerts"},
+					}}},
+				}},
+			},
+		},
+		"Modifiers": {
+			reason: "A missing optional label reference should remove the label and a fallback should replace a missing data source",
+			val: `{
+				"destinationDatasource": [{"uid": "Mimir ?? prom-uid"}],
+				"alerting": [{"slowburn": [{"label": [
+					{"key": "contact", "value": "contact-point:optional:payments"},
+					{"key": "oncall", "value": "oncall:optional:checkout-alerts"}
+				]}]}]
+			}`,
+			want: map[string]any{
+				"destinationDatasource": []any{map[string]any{"uid": "prom-uid"}},
+				"alerting": []any{map[string]any{"slowburn": []any{map[string]any{"label": []any{
+					map[string]any{"key": "oncall", "value": "checkout-alerts"},
+				}}}}},
+			},
+		},
+		"MissingReferences": {
+			reason: "Missing contact points and OnCall integrations should be reported",
+			val: `{"alerting": [{"label": [
+				{"key": "contact", "value": "contact-point:payments"},
+				{"key": "oncall", "value": "oncall:payments-alerts"}
+			]}]}`,
+			err: true,
+		},
+	}
+
+	c := NewSLOClient(nil, oncall.Grafana, alerting)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "slo.grafana.crossplane.io/v1alpha1", "kind": "SLO", "spec": {"forProvider": ` + tc.val + `}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -2,7 +2,11 @@ package main
 
 import (
 	"context"
+	"encoding/json"
+	"math"
 	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
 	SMAPI "github.com/grafana/synthetic-monitoring-api-go-client"
@@ -11,13 +15,23 @@ import (
 	"github.com/crossplane/function-sdk-go/resource"
 )
 
-// OnCallClient is a client with convenience methods
+const (
+	probeSelectorAllPublic    = "all-public"
+	probeSelectorLabelPrefix  = "label:"
+	probeSelectorRegionPrefix = "region="
+	probeSelectorPublicPrefix = "public="
+)
+
+// SMClient is a client with convenience methods
 type SMClient struct {
 	Client *SMAPI.Client
 	Probes []synthetic_monitoring.Probe
+
+	// Cloud is optional, it looks up the stack of an installation
+	Cloud *CloudClient
 }
 
-// NewOnCallClient returns a client with convenience methods
+// NewSMClient returns a client with convenience methods
 func NewSMClient(client *SMAPI.Client) *SMClient {
 	return &SMClient{
 		Client: client,
@@ -43,42 +57,210 @@ func (c *SMClient) getProbes() error {
 // Process processes fields of different kinds
 func (c *SMClient) Process(desired *resource.DesiredComposed) error {
 	gvk := desired.Resource.GroupVersionKind()
-	// switch gvk.Kind {
-	// case "Check":
-	if gvk.Kind == "Check" {
+	switch gvk.Kind {
+	case "Check":
 		path := "spec.forProvider.probes"
-		return replacePath(desired, path, c.GetProbes)
+		return replaceReferencePath(desired, path, c.GetProbes)
+
+	case "Installation":
+		if c.Cloud == nil {
+			return nil
+		}
+		path := "spec.forProvider.stackId"
+		return replaceReferencePath(desired, path, c.Cloud.GetStackID)
 	}
 	return nil
 }
 
-// GetProbes looks up probe IDs for given names
+// GetProbes looks up probe IDs for given names, IDs or selectors and returns them sorted
 func (c *SMClient) GetProbes(probes []any) ([]int64, error) {
 	probeIDs := []int64{}
 	for _, probe := range probes {
+		if selector, ok := probe.(string); ok && isProbeSelector(selector) {
+			selectedIDs, err := c.SelectProbes(selector)
+			if err != nil {
+				return nil, err
+			}
+			probeIDs = append(probeIDs, selectedIDs...)
+			continue
+		}
+
 		probeID, err := c.GetProbeID(probe)
 		if err != nil {
 			return nil, err
 		}
 		probeIDs = append(probeIDs, probeID)
 	}
+	slices.Sort(probeIDs)
+	return slices.Compact(probeIDs), nil
+}
+
+// isProbeSelector returns true if every term of the probe reference is a known selector term, other
+// references are probe names that may contain "=" or ","
+func isProbeSelector(probe string) bool {
+	for term := range strings.SplitSeq(probe, ",") {
+		term = strings.TrimSpace(term)
+		if term != probeSelectorAllPublic &&
+			!strings.HasPrefix(term, probeSelectorLabelPrefix) &&
+			!strings.HasPrefix(term, probeSelectorRegionPrefix) &&
+			!strings.HasPrefix(term, probeSelectorPublicPrefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// SelectProbes returns the IDs of all probes matching a selector, deprecated probes are never selected
+//
+// A selector is a comma separated list of terms that all need to match:
+//   - all-public
+//   - region=<region>
+//   - public=<true|false>
+//   - label:<name>=<value>
+func (c *SMClient) SelectProbes(selector string) ([]int64, error) {
+	if err := c.getProbes(); err != nil {
+		return nil, err
+	}
+
+	matchers := []func(synthetic_monitoring.Probe) bool{}
+	for term := range strings.SplitSeq(selector, ",") {
+		matcher, err := parseProbeSelectorTerm(strings.TrimSpace(term))
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid probe selector %s", selector)
+		}
+		matchers = append(matchers, matcher)
+	}
+
+	probeIDs := []int64{}
+	for _, probe := range c.Probes {
+		if probe.Deprecated {
+			continue
+		}
+		matched := true
+		for _, match := range matchers {
+			if !match(probe) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			probeIDs = append(probeIDs, probe.Id)
+		}
+	}
+
+	if len(probeIDs) == 0 {
+		return nil, errors.Errorf("Could not find probes matching selector %s", selector)
+	}
+
+	slices.Sort(probeIDs)
 	return probeIDs, nil
 }
 
-// GetProbeID looks up a probe ID for given name
+func parseProbeSelectorTerm(term string) (func(synthetic_monitoring.Probe) bool, error) {
+	if term == probeSelectorAllPublic {
+		return func(p synthetic_monitoring.Probe) bool {
+			return p.Public
+		}, nil
+	}
+
+	if label, ok := strings.CutPrefix(term, probeSelectorLabelPrefix); ok {
+		name, value, found := strings.Cut(label, "=")
+		if !found {
+			return nil, errors.Errorf("label term %s must be in the form label:<name>=<value>", term)
+		}
+		return func(p synthetic_monitoring.Probe) bool {
+			return slices.ContainsFunc(p.Labels, func(l synthetic_monitoring.Label) bool {
+				return l.Name == name && l.Value == value
+			})
+		}, nil
+	}
+
+	key, value, found := strings.Cut(term, "=")
+	if !found {
+		return nil, errors.Errorf("unknown term %s", term)
+	}
+	switch key {
+	case "region":
+		return func(p synthetic_monitoring.Probe) bool {
+			return strings.EqualFold(p.Region, value)
+		}, nil
+	case "public":
+		public, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid value for term %s", term)
+		}
+		return func(p synthetic_monitoring.Probe) bool {
+			return p.Public == public
+		}, nil
+	}
+	return nil, errors.Errorf("unknown term %s", term)
+}
+
+// GetProbeID looks up a probe ID for a numeric ID, a numeric string or a name
 func (c *SMClient) GetProbeID(probe any) (int64, error) {
 	if err := c.getProbes(); err != nil {
 		return -1, err
 	}
 
 	// WARNING: Probe names can't be set directly on the MRs, the `probes` field only accepts `number` while the probe names are `string`. I expect that a Composition will work as the probe names get replaced by numeric IDs before being applied to Kubernetes.
-	probeIDx := slices.IndexFunc(c.Probes, func(c synthetic_monitoring.Probe) bool {
-		return c.Id == probe || c.Name == probe
-	})
+	id, name, err := normaliseProbeRef(probe)
+	if err != nil {
+		return -1, err
+	}
 
-	if probeIDx != -1 {
-		return c.Probes[probeIDx].Id, nil
+	if id != nil {
+		probeIDx := slices.IndexFunc(c.Probes, func(c synthetic_monitoring.Probe) bool {
+			return c.Id == *id
+		})
+		if probeIDx != -1 {
+			return c.Probes[probeIDx].Id, nil
+		}
+	}
+
+	if name != "" {
+		probeIDx := slices.IndexFunc(c.Probes, func(c synthetic_monitoring.Probe) bool {
+			return c.Name == name
+		})
+		if probeIDx != -1 {
+			return c.Probes[probeIDx].Id, nil
+		}
 	}
 
-	return -1, errors.Errorf("Could not find probe with ID or name: %s", probe)
+	names := make([]string, 0, len(c.Probes))
+	for _, p := range c.Probes {
+		names = append(names, p.Name)
+	}
+	slices.Sort(names)
+
+	return -1, errors.Errorf("Could not find probe with ID or name: %v%s, available probes: %s", probe, didYouMean(name, names), strings.Join(names, ", "))
+}
+
+// normaliseProbeRef returns the ID and/or name a probe reference decoded from JSON may refer to
+func normaliseProbeRef(probe any) (*int64, string, error) {
+	switch p := probe.(type) {
+	case float64:
+		if p != math.Trunc(p) {
+			return nil, "", errors.Errorf("Invalid probe ID: %v", p)
+		}
+		id := int64(p)
+		return &id, "", nil
+	case int:
+		id := int64(p)
+		return &id, "", nil
+	case int64:
+		return &p, "", nil
+	case json.Number:
+		id, err := p.Int64()
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "Invalid probe ID: %s", p)
+		}
+		return &id, "", nil
+	case string:
+		// a numeric string may be an ID or a name
+		if id, err := strconv.ParseInt(p, 10, 64); err == nil {
+			return &id, p, nil
+		}
+		return nil, p, nil
+	}
+	return nil, "", errors.Errorf("Invalid probe reference %v of type %T", probe, probe)
 }
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/synthetic-monitoring-agent/pkg/pb/synthetic_monitoring"
+	SMAPI "github.com/grafana/synthetic-monitoring-api-go-client"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+var testProbes = []synthetic_monitoring.Probe{
+	{Id: 3, Name: "Frankfurt", Region: "EMEA", Public: true},
+	{Id: 1, Name: "London", Region: "EMEA", Public: true, Deprecated: true},
+	{Id: 2, Name: "Ohio", Region: "AMER", Public: true},
+	{Id: 10, Name: "payments-dc1", Region: "EMEA", Labels: []synthetic_monitoring.Label{{Name: "team", Value: "payments"}}},
+}
+
+func TestSMGetProbesSelectors(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		probes []any
+		want   []int64
+		err    bool
+	}{
+		"AllPublic": {
+			reason: "all-public should select all public probes that are not deprecated, sorted by ID",
+			probes: []any{"all-public"},
+			want:   []int64{2, 3},
+		},
+		"Region": {
+			reason: "A region selector should match the region case-insensitively",
+			probes: []any{"region=emea"},
+			want:   []int64{3, 10},
+		},
+		"CombinedTerms": {
+			reason: "All terms of a selector should match",
+			probes: []any{"region=EMEA,public=false"},
+			want:   []int64{10},
+		},
+		"LabelAndName": {
+			reason: "Selected probes and named probes should be merged without duplicates",
+			probes: []any{"label:team=payments", "Ohio", "payments-dc1"},
+			want:   []int64{2, 10},
+		},
+		"NoMatch": {
+			reason: "A selector without matches should return an error",
+			probes: []any{"region=APAC"},
+			err:    true,
+		},
+		"UnknownTerm": {
+			reason: "An unknown selector term should return an error",
+			probes: []any{"online=true"},
+			err:    true,
+		},
+	}
+
+	c := &SMClient{Probes: testProbes}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetProbes(tc.probes)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetProbes(...): unexpected error: %v", tc.reason, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetProbes(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
+
+func TestSMGetProbesNameWithEquals(t *testing.T) {
+	c := &SMClient{Probes: []synthetic_monitoring.Probe{
+		{Id: 4, Name: "dc=berlin", Region: "EMEA"},
+		{Id: 5, Name: "region=APAC", Region: "EMEA"},
+	}}
+
+	got, err := c.GetProbes([]any{"dc=berlin"})
+	if err != nil {
+		t.Fatalf("c.GetProbes(...): unexpected error: %v", err)
+	}
+	if diff := cmp.Diff([]int64{4}, got); diff != "" {
+		t.Errorf("A probe name with \"=\" should not be treated as a selector\nc.GetProbes(...): -want, +got:\n%s", diff)
+	}
+}
+
+// newTestSMClient returns an SMClient backed by a local SM API stand-in serving the given probes
+func newTestSMClient(t *testing.T, probes []synthetic_monitoring.Probe) *SMClient {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/probe/list", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, probes)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	return NewSMClient(SMAPI.NewClient(srv.URL, "token", srv.Client()))
+}
+
+func TestSMGetProbeID(t *testing.T) {
+	probes := append(slices.Clone(testProbes), synthetic_monitoring.Probe{Id: 20, Name: "3"})
+
+	cases := map[string]struct {
+		reason string
+		probe  any
+		want   int64
+		err    bool
+	}{
+		"NumericID": {
+			reason: "A numeric ID decoded from JSON should match the probe ID",
+			probe:  float64(2),
+			want:   2,
+		},
+		"JSONNumberID": {
+			reason: "A json.Number ID should match the probe ID",
+			probe:  json.Number("10"),
+			want:   10,
+		},
+		"NumericStringID": {
+			reason: "A numeric string should match the probe ID before the probe name",
+			probe:  "3",
+			want:   3,
+		},
+		"NumericStringName": {
+			reason: "A numeric string that is not an ID should match the probe name",
+			probe:  "20",
+			want:   20,
+		},
+		"Name": {
+			reason: "A name should match the probe name",
+			probe:  "Frankfurt",
+			want:   3,
+		},
+		"FractionalID": {
+			reason: "A fractional number is not a valid probe ID",
+			probe:  float64(2.5),
+			want:   -1,
+			err:    true,
+		},
+		"UnknownID": {
+			reason: "An unknown probe ID should return an error",
+			probe:  float64(42),
+			want:   -1,
+			err:    true,
+		},
+		"UnknownName": {
+			reason: "An unknown probe name should return an error",
+			probe:  "Paris",
+			want:   -1,
+			err:    true,
+		},
+	}
+
+	c := newTestSMClient(t, probes)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got, err := c.GetProbeID(tc.probe)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.GetProbeID(%v): unexpected error: %v", tc.reason, tc.probe, err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.GetProbeID(%v): -want, +got:\n%s", tc.reason, tc.probe, diff)
+			}
+		})
+	}
+}
+
+func TestSMGetProbeIDUnknownListsProbes(t *testing.T) {
+	c := newTestSMClient(t, testProbes)
+
+	_, err := c.GetProbeID("Paris")
+	want := "Could not find probe with ID or name: Paris, available probes: Frankfurt, London, Ohio, payments-dc1"
+	if diff := cmp.Diff(want, err.Error()); diff != "" {
+		t.Errorf("c.GetProbeID(...): -want err, +got err:\n%s", diff)
+	}
+}
+
+func TestSMGetProbeIDUnknownSuggestsProbes(t *testing.T) {
+	c := newTestSMClient(t, testProbes)
+
+	_, err := c.GetProbeID("frankfrut")
+	want := "Could not find probe with ID or name: frankfrut (did you mean: Frankfurt?), available probes: Frankfurt, London, Ohio, payments-dc1"
+	if diff := cmp.Diff(want, err.Error()); diff != "" {
+		t.Errorf("c.GetProbeID(...): -want err, +got err:\n%s", diff)
+	}
+}
+
+func TestSMCheckProbesModifiers(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		probes string
+		want   []any
+		err    bool
+	}{
+		"OptionalResolved": {
+			reason: "A resolved optional probe should be merged with the selected probes without duplicates",
+			probes: `["optional:Frankfurt", "region=EMEA"]`,
+			want:   []any{int64(3), int64(10)},
+		},
+		"OptionalMissing": {
+			reason: "A missing optional probe should be removed before the probes are sorted",
+			probes: `["optional:Paris", "region=EMEA"]`,
+			want:   []any{int64(3), int64(10)},
+		},
+		"Fallback": {
+			reason: "A fallback should be sorted with the selected probes",
+			probes: `["region=EMEA", "Paris ?? 2"]`,
+			want:   []any{int64(2), int64(3), int64(10)},
+		},
+		"Missing": {
+			reason: "A missing probe without modifiers should return an error",
+			probes: `["optional:Paris", "Paris"]`,
+			err:    true,
+		},
+	}
+
+	c := newTestSMClient(t, testProbes)
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desired := &resource.DesiredComposed{Resource: composed.New()}
+			obj := `{"apiVersion": "sm.grafana.crossplane.io/v1alpha1", "kind": "Check", "spec": {"forProvider": {"probes": ` + tc.probes + `}}}`
+			if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.Process(desired)
+			if (err != nil) != tc.err {
+				t.Fatalf("%s\nc.Process(...): unexpected error: %v", tc.reason, err)
+			}
+			if tc.err {
+				return
+			}
+
+			got, err := desired.Resource.GetValue("spec.forProvider.probes")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\nc.Process(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/request"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/response"
+)
+
+// publishInputFacts publishes the stack facts and the Frontend Observability apps of the Function
+// input to one desired composite resource, so neither overwrites what the other wrote, failed
+// lookups are reported as warnings
+func publishInputFacts(req *fnv1.RunFunctionRequest, rsp *fnv1.RunFunctionResponse, in *v1beta1.Input, getResolvers func(providerConfigName string) (map[string]resolver, error)) error {
+	if in.Stack == nil && in.FrontendApps == nil {
+		return nil
+	}
+
+	dxr, err := request.GetDesiredCompositeResource(req)
+	if err != nil {
+		return errors.Wrapf(err, "cannot get desired composite resource from %T", req)
+	}
+
+	if in.Stack != nil {
+		resolvers, err := getResolvers(in.Stack.ProviderConfigName)
+		if err != nil {
+			return errors.Errorf("cannot fetch client: %q", err)
+		}
+		if resolvers != nil {
+			if err := publishStackFacts(dxr, rsp, resolvers, in.Stack); err != nil {
+				response.Warning(rsp, err).TargetCompositeAndClaim()
+			}
+		}
+	}
+
+	if in.FrontendApps != nil {
+		resolvers, err := getResolvers(in.FrontendApps.ProviderConfigName)
+		if err != nil {
+			return errors.Errorf("cannot fetch client: %q", err)
+		}
+		if resolvers != nil {
+			if err := publishFrontendApps(dxr, rsp, resolvers, in.FrontendApps); err != nil {
+				response.Warning(rsp, err).TargetCompositeAndClaim()
+			}
+		}
+	}
+
+	if err := response.SetDesiredCompositeResource(rsp, dxr); err != nil {
+		return errors.Wrapf(err, "cannot set desired composite resource in %T", rsp)
+	}
+	return nil
+}
+
+// publishStackFacts looks up a Grafana Cloud stack and writes its endpoints and instance IDs to
+// the desired composite resource and the pipeline context
+func publishStackFacts(dxr *resource.Composite, rsp *fnv1.RunFunctionResponse, resolvers map[string]resolver, stack *v1beta1.Stack) error {
+	cloud, ok := resolvers["cloud.grafana.crossplane.io"].(*CloudClient)
+	if !ok {
+		return errors.Errorf("providerConfig %s has no Grafana Cloud credentials to look up stack %s", stack.ProviderConfigName, stack.Slug)
+	}
+
+	facts, err := cloud.GetStackFacts(stack.Slug)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]any, len(facts))
+	for k, v := range facts {
+		values[k] = v
+	}
+
+	var connectionDetails map[string]string
+	if stack.ConnectionDetails {
+		connectionDetails = facts
+	}
+	return publishFacts(dxr, rsp, values, stack.StatusField, connectionDetails, stack.ContextKey)
+}
+
+// publishFrontendApps looks up Frontend Observability apps and writes their collector endpoint
+// and app key by app name to the desired composite resource and the pipeline context
+func publishFrontendApps(dxr *resource.Composite, rsp *fnv1.RunFunctionResponse, resolvers map[string]resolver, apps *v1beta1.FrontendApps) error {
+	frontendO11y, ok := resolvers["frontendobservability.grafana.crossplane.io"].(*FrontendO11yClient)
+	if !ok {
+		return errors.Errorf("providerConfig %s has no Frontend Observability client", apps.ProviderConfigName)
+	}
+
+	values := make(map[string]any, len(apps.Names))
+	for _, name := range apps.Names {
+		facts, err := frontendO11y.GetAppFacts(apps.StackSlug, name)
+		if err != nil {
+			return err
+		}
+		appValues := make(map[string]any, len(facts))
+		for k, v := range facts {
+			appValues[k] = v
+		}
+		values[name] = appValues
+	}
+
+	return publishFacts(dxr, rsp, values, apps.StatusField, nil, apps.ContextKey)
+}
+
+// publishFacts writes facts to a field of the desired composite resource, its connection details
+// and a pipeline context key, each of them is optional, the caller sets the desired composite
+// resource once all facts are written
+func publishFacts(dxr *resource.Composite, rsp *fnv1.RunFunctionResponse, values map[string]any, statusField string, connectionDetails map[string]string, contextKey string) error {
+	if statusField != "" {
+		if err := dxr.Resource.SetValue(statusField, values); err != nil {
+			return errors.Wrapf(err, "cannot set facts at %s", statusField)
+		}
+	}
+
+	if connectionDetails != nil {
+		if dxr.ConnectionDetails == nil {
+			dxr.ConnectionDetails = map[string][]byte{}
+		}
+		for k, v := range connectionDetails {
+			dxr.ConnectionDetails[k] = []byte(v)
+		}
+	}
+
+	if contextKey != "" {
+		v, err := structpb.NewValue(values)
+		if err != nil {
+			return errors.Wrapf(err, "cannot convert facts for context key %s", contextKey)
+		}
+		response.SetContextKey(rsp, contextKey, v)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/grafana/crossplane-function-grafana-data/input/v1beta1"
+	"github.com/grafana/crossplane-function-grafana-data/pkg/clients"
+	"github.com/grafana/grafana-com-public-clients/go/gcom"
+
+	fnv1 "github.com/crossplane/function-sdk-go/proto/v1"
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/response"
+)
+
+var (
+	testStackFacts = map[string]any{
+		"stackId":              "123",
+		"stackSlug":            "mystack",
+		"stackUrl":             "https://mystack.grafana.net",
+		"prometheusUrl":        "https://prometheus.grafana.net",
+		"prometheusInstanceId": "456",
+		"lokiUrl":              "https://logs.grafana.net",
+		"lokiInstanceId":       "789",
+		"tempoUrl":             "https://tempo.grafana.net",
+		"tempoInstanceId":      "321",
+		"otlpUrl":              "https://otlp.grafana.net/otlp",
+	}
+	testFrontendApps = map[string]any{
+		"shop": map[string]any{
+			"appId":             "1",
+			"appName":           "shop",
+			"appKey":            "key-1",
+			"collectorEndpoint": "https://faro-collector.grafana.net/collect/key-1",
+		},
+	}
+)
+
+// newTestPublishResolvers returns the Grafana Cloud and Frontend Observability resolvers backed by
+// a local stand-in for both APIs
+func newTestPublishResolvers(t *testing.T) map[string]resolver {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/instances/mystack", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{
+			"id":                123,
+			"slug":              "mystack",
+			"url":               "https://mystack.grafana.net",
+			"regionSlug":        "prod-eu-west-2",
+			"createdAt":         "2024-01-02T15:04:05Z",
+			"hmInstancePromId":  456,
+			"hmInstancePromUrl": "https://prometheus.grafana.net",
+			"hlInstanceId":      789,
+			"hlInstanceUrl":     "https://logs.grafana.net",
+			"htInstanceId":      321,
+			"htInstanceUrl":     "https://tempo.grafana.net",
+		})
+	})
+	mux.HandleFunc("/instances/mystack/connections", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, map[string]any{"otlpHttpUrl": "https://otlp.grafana.net/otlp"})
+	})
+	mux.HandleFunc("/api/v1/app", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer 123:token" {
+			t.Errorf("unexpected Authorization header: %q", got)
+		}
+		writeJSON(t, w, []map[string]any{
+			{"id": 1, "name": "shop", "appKey": "key-1", "collectEndpointURL": "https://faro-collector.grafana.net/collect"},
+			{"id": 2, "name": "admin", "appKey": "key-2", "collectEndpointURL": "https://faro-collector.grafana.net/collect"},
+		})
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	cfg := gcom.NewConfiguration()
+	cfg.Servers = gcom.ServerConfigurations{{URL: srv.URL}}
+	cloud := NewCloudClient(gcom.NewAPIClient(cfg))
+
+	frontendO11y, err := clients.NewFrontendO11yClient(srv.URL, "https://grafana.com", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]resolver{
+		"cloud.grafana.crossplane.io":                 cloud,
+		"frontendobservability.grafana.crossplane.io": NewFrontendO11yClient(frontendO11y, cloud),
+	}
+}
+
+func TestPublishInputFacts(t *testing.T) {
+	stack := &v1beta1.Stack{
+		Slug:               "mystack",
+		ProviderConfigName: "cloud",
+		StatusField:        "status.stack",
+		ConnectionDetails:  true,
+		ContextKey:         "grafana.fn.crossplane.io/stack",
+	}
+	apps := &v1beta1.FrontendApps{
+		StackSlug:          "mystack",
+		ProviderConfigName: "cloud",
+		Names:              []string{"shop"},
+		StatusField:        "status.frontendApps",
+		ContextKey:         "grafana.fn.crossplane.io/frontend-apps",
+	}
+
+	cases := map[string]struct {
+		reason     string
+		in         *v1beta1.Input
+		wantStatus map[string]any
+		wantConn   string
+	}{
+		"Stack": {
+			reason:     "The stack facts should be written to the status, connection details and context",
+			in:         &v1beta1.Input{Stack: stack},
+			wantStatus: map[string]any{"stack": testStackFacts},
+			wantConn:   "456",
+		},
+		"FrontendApps": {
+			reason:     "The Frontend Observability apps should be written to the status and context",
+			in:         &v1beta1.Input{FrontendApps: apps},
+			wantStatus: map[string]any{"frontendApps": testFrontendApps},
+		},
+		"Both": {
+			reason:     "The Frontend Observability apps should not drop the stack facts written before them",
+			in:         &v1beta1.Input{Stack: stack, FrontendApps: apps},
+			wantStatus: map[string]any{"stack": testStackFacts, "frontendApps": testFrontendApps},
+			wantConn:   "456",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			resolvers := newTestPublishResolvers(t)
+			req := &fnv1.RunFunctionRequest{
+				Desired: &fnv1.State{
+					Composite: &fnv1.Resource{
+						Resource: resource.MustStructJSON(`{"apiVersion": "example.org/v1", "kind": "XStack"}`),
+					},
+				},
+			}
+			rsp := response.To(req, response.DefaultTTL)
+
+			err := publishInputFacts(req, rsp, tc.in, func(string) (map[string]resolver, error) {
+				return resolvers, nil
+			})
+			if err != nil {
+				t.Fatalf("%s\npublishInputFacts(...): unexpected error: %v", tc.reason, err)
+			}
+			if results := rsp.GetResults(); len(results) != 0 {
+				t.Fatalf("%s\npublishInputFacts(...): unexpected results: %v", tc.reason, results)
+			}
+
+			composite := rsp.GetDesired().GetComposite()
+			if diff := cmp.Diff(tc.wantStatus, composite.GetResource().AsMap()["status"]); diff != "" {
+				t.Errorf("%s\npublishInputFacts(...): status -want, +got:\n%s", tc.reason, diff)
+			}
+			if diff := cmp.Diff(tc.wantConn, string(composite.GetConnectionDetails()["prometheusInstanceId"])); diff != "" {
+				t.Errorf("%s\npublishInputFacts(...): connection details -want, +got:\n%s", tc.reason, diff)
+			}
+
+			context := rsp.GetContext().AsMap()
+			if tc.in.Stack != nil {
+				if diff := cmp.Diff(testStackFacts, context["grafana.fn.crossplane.io/stack"]); diff != "" {
+					t.Errorf("%s\npublishInputFacts(...): stack context -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+			if tc.in.FrontendApps != nil {
+				if diff := cmp.Diff(testFrontendApps, context["grafana.fn.crossplane.io/frontend-apps"]); diff != "" {
+					t.Errorf("%s\npublishInputFacts(...): apps context -want, +got:\n%s", tc.reason, diff)
+				}
+			}
+		})
+	}
+}
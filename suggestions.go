@@ -0,0 +1,81 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// suggestionLimit is the number of closest matches a not-found error suggests
+const suggestionLimit = 3
+
+// suggest returns the candidates closest to a name, case-insensitive matches come first and the
+// others by edit distance, candidates that are too different are left out
+func suggest(name string, candidates []string) []string {
+	type suggestion struct {
+		candidate string
+		distance  int
+	}
+
+	lower := strings.ToLower(name)
+	// allow about one typo for every three characters
+	maxDistance := max(2, len([]rune(name))/3)
+
+	suggestions := []suggestion{}
+	for _, candidate := range dedupe(candidates) {
+		if candidate == "" || candidate == name {
+			continue
+		}
+		distance := 0
+		if !strings.EqualFold(candidate, name) {
+			distance = 1 + editDistance(lower, strings.ToLower(candidate))
+		}
+		if distance <= maxDistance+1 {
+			suggestions = append(suggestions, suggestion{candidate: candidate, distance: distance})
+		}
+	}
+
+	slices.SortFunc(suggestions, func(a, b suggestion) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+		return strings.Compare(a.candidate, b.candidate)
+	})
+
+	names := []string{}
+	for _, s := range suggestions[:min(len(suggestions), suggestionLimit)] {
+		names = append(names, s.candidate)
+	}
+	return names
+}
+
+// didYouMean returns a hint with the candidates closest to a name for a not-found error, or an
+// empty string when none is close
+func didYouMean(name string, candidates []string) string {
+	names := suggest(name, candidates)
+	if len(names) == 0 {
+		return ""
+	}
+	return " (did you mean: " + strings.Join(names, ", ") + "?)"
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDidYouMean(t *testing.T) {
+	candidates := []string{"alice", "alice@example.com", "Platform", "platform-eu", "payments", "bob"}
+
+	cases := map[string]struct {
+		reason string
+		name   string
+		want   string
+	}{
+		"CaseInsensitive": {
+			reason: "A case-insensitive match should come first",
+			name:   "platform",
+			want:   " (did you mean: Platform?)",
+		},
+		"Typo": {
+			reason: "A name with a typo should suggest the closest candidates",
+			name:   "alcie",
+			want:   " (did you mean: alice?)",
+		},
+		"Email": {
+			reason: "A misspelled email should suggest the email",
+			name:   "alice@exmaple.com",
+			want:   " (did you mean: alice@example.com?)",
+		},
+		"NoMatch": {
+			reason: "A name unlike any candidate should not suggest anything",
+			name:   "observability",
+			want:   "",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			got := didYouMean(tc.name, candidates)
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\ndidYouMean(%q, ...): -want, +got:\n%s", tc.reason, tc.name, diff)
+			}
+		})
+	}
+}
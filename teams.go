@@ -0,0 +1,99 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/crossplane/function-sdk-go/errors"
+	"github.com/crossplane/function-sdk-go/resource"
+)
+
+// checkTeamExternalGroups returns a warning for each team that maps an external group more than
+// once and for each external group that is mapped to several teams, across the team sync
+// settings of Team and the groups of TeamExternalGroup in the desired composed resources
+func checkTeamExternalGroups(desiredComposed map[resource.Name]*resource.DesiredComposed) []error {
+	// a teamRef refers to a Team by its resource name, the check uses the team name instead, composed
+	// resources rarely set metadata.name, so they are known by their composed resource name
+	teamNames := map[string]string{}
+	for resourceName, desired := range desiredComposed {
+		if !isTeam(desired) {
+			continue
+		}
+		name, err := desired.Resource.GetString("spec.forProvider.name")
+		if err != nil {
+			continue
+		}
+		teamNames[string(resourceName)] = name
+		if metadataName := desired.Resource.GetName(); metadataName != "" {
+			teamNames[metadataName] = name
+		}
+	}
+
+	teams := map[string][]string{}
+	for _, desired := range desiredComposed {
+		team, groups := teamExternalGroups(desired, teamNames)
+		if team != "" {
+			teams[team] = append(teams[team], groups...)
+		}
+	}
+
+	warnings := []error{}
+	groupTeams := map[string][]string{}
+	for _, team := range slices.Sorted(maps.Keys(teams)) {
+		counts := map[string]int{}
+		for _, group := range teams[team] {
+			counts[group]++
+		}
+		for _, group := range slices.Sorted(maps.Keys(counts)) {
+			if counts[group] > 1 {
+				warnings = append(warnings, errors.Errorf("Team %s maps external group %s %d times", team, group, counts[group]))
+			}
+			groupTeams[group] = append(groupTeams[group], team)
+		}
+	}
+
+	for _, group := range slices.Sorted(maps.Keys(groupTeams)) {
+		if t := groupTeams[group]; len(t) > 1 {
+			warnings = append(warnings, errors.Errorf("External group %s is mapped to %d teams: %s", group, len(t), strings.Join(t, ", ")))
+		}
+	}
+	return warnings
+}
+
+func isTeam(desired *resource.DesiredComposed) bool {
+	gvk := desired.Resource.GroupVersionKind()
+	return gvk.Group == "oss.grafana.crossplane.io" && gvk.Kind == "Team"
+}
+
+// teamExternalGroups returns the team and the external groups of a Team or TeamExternalGroup
+func teamExternalGroups(desired *resource.DesiredComposed, teamNames map[string]string) (string, []string) {
+	var (
+		team   string
+		groups []string
+	)
+	gvk := desired.Resource.GroupVersionKind()
+	switch {
+	case gvk.Group == "enterprise.grafana.crossplane.io" && gvk.Kind == "TeamExternalGroup":
+		if id, err := desired.Resource.GetString(pathTeamID); err == nil {
+			team = parseReference(id).Name
+		} else if ref, err := desired.Resource.GetString("spec.forProvider.teamRef.name"); err == nil {
+			team = ref
+			if name, ok := teamNames[ref]; ok {
+				team = name
+			}
+		}
+		_ = desired.Resource.GetValueInto("spec.forProvider.groups", &groups)
+
+	case isTeam(desired):
+		team, _ = desired.Resource.GetString("spec.forProvider.name")
+		var teamSync []struct {
+			Groups []string `json:"groups"`
+		}
+		_ = desired.Resource.GetValueInto("spec.forProvider.teamSync", &teamSync)
+		for _, ts := range teamSync {
+			groups = append(groups, ts.Groups...)
+		}
+	}
+	return team, groups
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/crossplane/function-sdk-go/resource"
+	"github.com/crossplane/function-sdk-go/resource/composed"
+)
+
+func TestCheckTeamExternalGroups(t *testing.T) {
+	cases := map[string]struct {
+		reason string
+		objs   map[string]string
+		want   []string
+	}{
+		"NoDuplicates": {
+			reason: "Distinct groups of distinct teams should not be reported",
+			objs: map[string]string{
+				"platform": `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"name": "Platform", "teamSync": [{"groups": ["sso-platform"]}]}}}`,
+				"payments": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamId": "Payments", "groups": ["sso-payments"]}}}`,
+			},
+			want: []string{},
+		},
+		"DuplicateMapping": {
+			reason: "A group mapped twice to the same team should be reported, a teamRef refers to the Team by name",
+			objs: map[string]string{
+				"platform":        `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"name": "Platform", "teamSync": [{"groups": ["sso-platform"]}]}}}`,
+				"platform-groups": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamRef": {"name": "platform"}, "groups": ["sso-platform"]}}}`,
+			},
+			want: []string{"Team Platform maps external group sso-platform 2 times"},
+		},
+		"ModifiedTeamID": {
+			reason: "The team of a teamId with reference modifiers should be compared without the modifiers",
+			objs: map[string]string{
+				"platform":        `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"name": "Platform", "teamSync": [{"groups": ["sso-platform"]}]}}}`,
+				"platform-groups": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamId": "optional:Platform", "groups": ["sso-platform"]}}}`,
+			},
+			want: []string{"Team Platform maps external group sso-platform 2 times"},
+		},
+		"TeamRefByComposedName": {
+			reason: "A teamRef should refer to the Team by its composed resource name when the Teams have no metadata name",
+			objs: map[string]string{
+				"platform":        `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"name": "Platform", "teamSync": [{"groups": ["sso-platform"]}]}}}`,
+				"payments":        `{"apiVersion": "oss.grafana.crossplane.io/v1alpha1", "kind": "Team", "spec": {"forProvider": {"name": "Payments", "teamSync": [{"groups": ["sso-payments"]}]}}}`,
+				"payments-groups": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamRef": {"name": "payments"}, "groups": ["sso-payments"]}}}`,
+			},
+			want: []string{"Team Payments maps external group sso-payments 2 times"},
+		},
+		"SeveralTeams": {
+			reason: "A group mapped to several teams should be reported",
+			objs: map[string]string{
+				"a": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamId": "Payments", "groups": ["sso-all", "sso-payments"]}}}`,
+				"b": `{"apiVersion": "enterprise.grafana.crossplane.io/v1alpha1", "kind": "TeamExternalGroup", "spec": {"forProvider": {"teamId": "Platform", "groups": ["sso-all"]}}}`,
+			},
+			want: []string{"External group sso-all is mapped to 2 teams: Payments, Platform"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			desiredComposed := map[resource.Name]*resource.DesiredComposed{}
+			for name, obj := range tc.objs {
+				desired := &resource.DesiredComposed{Resource: composed.New()}
+				if err := desired.Resource.UnmarshalJSON([]byte(obj)); err != nil {
+					t.Fatal(err)
+				}
+				desiredComposed[resource.Name(name)] = desired
+			}
+
+			got := []string{}
+			for _, err := range checkTeamExternalGroups(desiredComposed) {
+				got = append(got, err.Error())
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("%s\ncheckTeamExternalGroups(...): -want, +got:\n%s", tc.reason, diff)
+			}
+		})
+	}
+}